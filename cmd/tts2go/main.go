@@ -10,8 +10,10 @@ import (
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
 
+	"tts2go/internal/pkg/tts2go/alignment"
 	"tts2go/internal/pkg/tts2go/config"
 	"tts2go/internal/pkg/tts2go/model"
+	"tts2go/internal/pkg/tts2go/tokenizer"
 )
 
 func main() {
@@ -31,18 +33,44 @@ func main() {
 
 	log.Debug().
 		Str("model", cfg.ModelPath).
+		Str("model_type", cfg.ModelType).
 		Str("voices", cfg.VoicesPath).
+		Str("vocab", cfg.VocabPath).
+		Str("lexicon", cfg.LexiconPath).
 		Str("voice", cfg.Voice).
+		Str("lang", cfg.Language).
 		Float32("speed", cfg.Speed).
+		Dur("chunk_silence", cfg.ChunkSilence).
 		Msg("Configuration loaded")
 
 	log.Info().Msg("Loading TTS model...")
-	tts, err := model.NewTTS(cfg.ModelPath, cfg.VoicesPath)
+	tts, err := model.NewTTS(cfg.ModelPath, cfg.VoicesPath, model.Options{
+		ModelType:    cfg.ModelType,
+		Language:     cfg.Language,
+		VocabPath:    cfg.VocabPath,
+		LexiconPath:  cfg.LexiconPath,
+		DateOrder:    cfg.DateOrder,
+		OOVPolicy:    cfg.OOVPolicy,
+		ChunkSilence: cfg.ChunkSilence,
+		OnUnknownSymbols: func(phonemes string, unknowns []tokenizer.Unknown) {
+			for _, u := range unknowns {
+				log.Warn().
+					Str("phoneme", string(u.Rune)).
+					Str("codepoint", fmt.Sprintf("U+%04X", u.Rune)).
+					Int("pos", u.Pos).
+					Str("mapped", u.Mapped).
+					Str("phonemes", truncateText(phonemes, 50)).
+					Msg("Phoneme not in model vocabulary")
+			}
+		},
+	})
 	if err != nil {
 		log.Fatal().Err(err).Msg("Failed to load model")
 	}
 	defer tts.Close()
 
+	log.Info().Str("model_type", tts.ModelType()).Msg("Model loaded")
+
 	voices := tts.ListVoices()
 	sort.Strings(voices)
 
@@ -54,6 +82,13 @@ func main() {
 		return
 	}
 
+	if cfg.Command == config.CommandServe {
+		if err := serve(cfg, tts); err != nil {
+			log.Fatal().Err(err).Msg("Server stopped")
+		}
+		return
+	}
+
 	if cfg.Voice == "" {
 		if len(voices) == 0 {
 			log.Fatal().Msg("No voices available")
@@ -64,10 +99,36 @@ func main() {
 
 	log.Debug().Strs("voices", voices).Msg("Available voices")
 
-	log.Info().Str("text", truncateText(cfg.Text, 50)).Msg("Generating speech...")
+	format, err := outputFormat(cfg)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Invalid output format")
+	}
+	writeOpts, err := writeOptions(cfg)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Invalid output format")
+	}
+	cfg.SampleRate = outputSampleRate(cfg, format, writeOpts)
+	timingsFmt, err := timingsFormat(cfg)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Invalid timings format")
+	}
+
+	log.Info().
+		Str("text", truncateText(cfg.Text, 50)).
+		Str("lang", tts.Language(cfg.Voice)).
+		Msg("Generating speech...")
+
+	if cfg.Stream {
+		if err := streamAudio(cfg, tts, format, writeOpts, timingsFmt); err != nil {
+			log.Fatal().Err(err).Msg("Failed to stream audio")
+		}
+		log.Info().Str("output", cfg.Output).Msg("Audio saved successfully")
+		return
+	}
+
 	startTime := time.Now()
 
-	audio, err := tts.Generate(cfg.Text, cfg.Voice, cfg.Speed)
+	audio, words, err := tts.GenerateWithTimings(cfg.Text, cfg.Voice, cfg.Speed)
 	if err != nil {
 		log.Fatal().Err(err).Msg("Failed to generate audio")
 	}
@@ -78,10 +139,20 @@ func main() {
 		Float64("duration_sec", audio.Duration()).
 		Msg("Audio generated")
 
-	if err := audio.SaveWAV(cfg.Output); err != nil {
+	audio, shift := postProcess(audio, cfg, true, true, nil)
+	alignment.Shift(words, shift)
+
+	if err := saveAudio(audio, cfg.Output, format, writeOpts); err != nil {
 		log.Fatal().Err(err).Msg("Failed to save audio")
 	}
 
+	if cfg.Timings != "" {
+		if err := saveTimings(words, cfg.Timings, timingsFmt); err != nil {
+			log.Fatal().Err(err).Msg("Failed to save timings")
+		}
+		log.Info().Str("timings", cfg.Timings).Int("words", len(words)).Msg("Timings saved")
+	}
+
 	log.Info().Str("output", cfg.Output).Msg("Audio saved successfully")
 }
 
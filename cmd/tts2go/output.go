@@ -0,0 +1,198 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"tts2go/internal/pkg/tts2go/alignment"
+	"tts2go/internal/pkg/tts2go/audio"
+	"tts2go/internal/pkg/tts2go/config"
+	"tts2go/internal/pkg/tts2go/model"
+)
+
+func outputFormat(cfg *config.Config) (string, error) {
+	format := cfg.Format
+	if format == "" {
+		format = audio.FormatFromPath(cfg.Output)
+	}
+	if !slices.Contains(audio.Formats, format) {
+		return "", fmt.Errorf("unsupported output format: %s (want %s)", format, strings.Join(audio.Formats, ", "))
+	}
+	return format, nil
+}
+
+func writeOptions(cfg *config.Config) (audio.WriteOptions, error) {
+	sampleFormat, err := audio.ParseSampleFormat(cfg.SampleFormat)
+	if err != nil {
+		return audio.WriteOptions{}, err
+	}
+	return audio.WriteOptions{SampleFormat: sampleFormat, Dither: cfg.Dither}, nil
+}
+
+// outputSampleRate is the configured rate, or the rate the output format
+// requires (8 kHz for G.711) if none is configured.
+func outputSampleRate(cfg *config.Config, format string, opts audio.WriteOptions) int {
+	if cfg.SampleRate != 0 {
+		return cfg.SampleRate
+	}
+	return audio.FormatSampleRate(format, opts)
+}
+
+// postProcess trims, fades and pads the edges of generated audio, then
+// converts it to the configured output rate and loudness. When streaming,
+// first and last say which edges of the whole output the chunk holds. The
+// returned shift is how far the start of the speech moved, for adjusting
+// word timings. rs carries the resampler state from one streamed chunk to
+// the next; if nil, a is resampled on its own.
+func postProcess(a *audio.Audio, cfg *config.Config, first, last bool, rs *audio.Resampler) (*audio.Audio, time.Duration) {
+	var shift time.Duration
+	if first {
+		if cfg.Trim {
+			trimmed := a.TrimStart(cfg.TrimThreshold)
+			shift -= samplesDuration(len(a.Samples)-len(trimmed.Samples), a.SampleRate)
+			a = trimmed
+		}
+		a = a.FadeIn(cfg.FadeIn)
+		shift += cfg.PadStart
+	}
+	if last {
+		if cfg.Trim {
+			a = a.TrimEnd(cfg.TrimThreshold)
+		}
+		a = a.FadeOut(cfg.FadeOut)
+	}
+	a = a.Pad(padIf(first, cfg.PadStart), padIf(last, cfg.PadEnd))
+
+	if rs == nil {
+		rs = audio.NewResampler(a.SampleRate, cfg.SampleRate)
+	}
+	a = rs.Resample(a, last)
+	if cfg.Loudness != 0 {
+		a = a.NormalizeLoudness(cfg.Loudness, cfg.TruePeak)
+	}
+	return a, shift
+}
+
+func samplesDuration(n, sampleRate int) time.Duration {
+	return time.Duration(n) * time.Second / time.Duration(sampleRate)
+}
+
+func padIf(edge bool, d time.Duration) time.Duration {
+	if !edge {
+		return 0
+	}
+	return d
+}
+
+func openOutput(path string) (io.WriteCloser, error) {
+	if path == "-" {
+		return nopWriteCloser{os.Stdout}, nil
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create file: %w", err)
+	}
+	return f, nil
+}
+
+type nopWriteCloser struct {
+	*os.File
+}
+
+func (nopWriteCloser) Close() error { return nil }
+
+func timingsFormat(cfg *config.Config) (string, error) {
+	format := cfg.TimingsFormat
+	if format == "" {
+		format = alignment.FormatFromPath(cfg.Timings)
+	}
+	if !slices.Contains(alignment.Formats, format) {
+		return "", fmt.Errorf("unsupported timings format: %s (want %s)", format, strings.Join(alignment.Formats, ", "))
+	}
+	return format, nil
+}
+
+func saveTimings(words []alignment.Word, path, format string) error {
+	out, err := openOutput(path)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	if err := alignment.Write(out, words, format); err != nil {
+		return fmt.Errorf("failed to write timings: %w", err)
+	}
+	return out.Close()
+}
+
+func saveAudio(a *audio.Audio, path, format string, opts audio.WriteOptions) error {
+	out, err := openOutput(path)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	if err := a.Encode(out, format, opts); err != nil {
+		return err
+	}
+	return out.Close()
+}
+
+// streamAudio writes each synthesized chunk to the output as soon as it is
+// ready, so playback can start after the first sentence.
+func streamAudio(cfg *config.Config, tts *model.TTS, format string, opts audio.WriteOptions, timingsFormat string) error {
+	out, err := openOutput(cfg.Output)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	sw, err := audio.NewStreamWriter(out, format, opts)
+	if err != nil {
+		return err
+	}
+
+	start := time.Now()
+	var chunks int
+	var duration float64
+	var words []alignment.Word
+	var shift time.Duration
+	var rs *audio.Resampler
+	err = tts.GenerateStream(cfg.Text, cfg.Voice, cfg.Speed, func(part model.StreamPart) error {
+		if part.First() {
+			log.Info().Dur("elapsed", time.Since(start)).Msg("First audio chunk ready")
+			rs = audio.NewResampler(part.Audio.SampleRate, cfg.SampleRate)
+		}
+		chunks++
+		a, partShift := postProcess(part.Audio, cfg, part.First(), part.Last(), rs)
+		shift += partShift
+		alignment.Shift(part.Words, shift)
+		words = append(words, part.Words...)
+		duration += a.Duration()
+		return sw.Write(a)
+	})
+	if err != nil {
+		return err
+	}
+	if err := sw.Close(); err != nil {
+		return err
+	}
+	if cfg.Timings != "" {
+		if err := saveTimings(words, cfg.Timings, timingsFormat); err != nil {
+			return err
+		}
+	}
+
+	log.Info().
+		Dur("elapsed", time.Since(start)).
+		Int("chunks", chunks).
+		Float64("duration_sec", duration).
+		Msg("Audio streamed")
+	return out.Close()
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"tts2go/internal/pkg/tts2go/config"
+	"tts2go/internal/pkg/tts2go/model"
+	"tts2go/internal/pkg/tts2go/server"
+)
+
+const shutdownTimeout = 10 * time.Second
+
+func serve(cfg *config.Config, tts *model.TTS) error {
+	handler := server.New(tts, server.Defaults{
+		Voice:        cfg.Voice,
+		Speed:        cfg.Speed,
+		VoiceAliases: cfg.VoiceAliases,
+		SampleRate:   cfg.SampleRate,
+		Loudness:     cfg.Loudness,
+		TruePeak:     cfg.TruePeak,
+	}, log.Logger)
+
+	srv := &http.Server{
+		Addr:              cfg.Listen,
+		Handler:           handler,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	errCh := make(chan error, 1)
+	go func() {
+		log.Info().Str("listen", cfg.Listen).Msg("Serving TTS over HTTP")
+		errCh <- srv.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errCh:
+		if !errors.Is(err, http.ErrServerClosed) {
+			return fmt.Errorf("server failed: %w", err)
+		}
+		return nil
+	case <-ctx.Done():
+	}
+
+	log.Info().Msg("Shutting down server...")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		return fmt.Errorf("server shutdown failed: %w", err)
+	}
+	return nil
+}
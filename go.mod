@@ -8,6 +8,7 @@ replace github.com/neurlang/goruut => ../goruut
 
 require (
 	github.com/neurlang/goruut v0.0.0-00010101000000-000000000000
+	github.com/pelletier/go-toml/v2 v2.2.4
 	github.com/rs/zerolog v1.34.0
 	github.com/spf13/pflag v1.0.10
 	github.com/spf13/viper v1.21.0
@@ -28,7 +29,6 @@ require (
 	github.com/neurlang/classifier v0.3.0 // indirect
 	github.com/neurlang/noaregtransformer/go v0.0.0-20260210165246-8343b31cc031 // indirect
 	github.com/neurlang/quaternary v0.2.4 // indirect
-	github.com/pelletier/go-toml/v2 v2.2.4 // indirect
 	github.com/sagikazarmark/locafero v0.11.0 // indirect
 	github.com/sentencizer/sentencizer v0.1.8 // indirect
 	github.com/sirupsen/logrus v1.9.3 // indirect
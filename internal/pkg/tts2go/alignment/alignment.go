@@ -0,0 +1,43 @@
+package alignment
+
+import "time"
+
+const (
+	FormatJSON = "json"
+	FormatSRT  = "srt"
+	FormatVTT  = "vtt"
+)
+
+var Formats = []string{FormatJSON, FormatSRT, FormatVTT}
+
+// Word is a spoken word and when it is heard in the output audio.
+type Word struct {
+	Text     string
+	Start    time.Duration
+	End      time.Duration
+	Phonemes []Phoneme
+}
+
+// Phoneme is one model symbol of a word.
+type Phoneme struct {
+	Symbol string
+	Start  time.Duration
+	End    time.Duration
+}
+
+// Shift moves all timings by d, clamping at zero.
+func Shift(words []Word, d time.Duration) {
+	if d == 0 {
+		return
+	}
+	for i := range words {
+		w := &words[i]
+		w.Start = max(w.Start+d, 0)
+		w.End = max(w.End+d, 0)
+		for j := range w.Phonemes {
+			p := &w.Phonemes[j]
+			p.Start = max(p.Start+d, 0)
+			p.End = max(p.End+d, 0)
+		}
+	}
+}
@@ -0,0 +1,138 @@
+package alignment
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	maxCueWords    = 7
+	maxCueDuration = 5 * time.Second
+)
+
+// FormatFromPath picks a timings format from a file extension, defaulting
+// to JSON.
+func FormatFromPath(path string) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".srt":
+		return FormatSRT
+	case ".vtt":
+		return FormatVTT
+	default:
+		return FormatJSON
+	}
+}
+
+func Write(w io.Writer, words []Word, format string) error {
+	switch strings.ToLower(format) {
+	case "", FormatJSON:
+		return WriteJSON(w, words)
+	case FormatSRT:
+		return WriteSRT(w, words)
+	case FormatVTT:
+		return WriteVTT(w, words)
+	default:
+		return fmt.Errorf("unsupported timings format: %s", format)
+	}
+}
+
+type jsonPhoneme struct {
+	Symbol string  `json:"symbol"`
+	Start  float64 `json:"start"`
+	End    float64 `json:"end"`
+}
+
+type jsonWord struct {
+	Text     string        `json:"text"`
+	Start    float64       `json:"start"`
+	End      float64       `json:"end"`
+	Phonemes []jsonPhoneme `json:"phonemes,omitempty"`
+}
+
+// WriteJSON writes {"words": [...]} with times in seconds.
+func WriteJSON(w io.Writer, words []Word) error {
+	out := make([]jsonWord, 0, len(words))
+	for _, word := range words {
+		jw := jsonWord{
+			Text:  word.Text,
+			Start: word.Start.Seconds(),
+			End:   word.End.Seconds(),
+		}
+		for _, p := range word.Phonemes {
+			jw.Phonemes = append(jw.Phonemes, jsonPhoneme{
+				Symbol: p.Symbol,
+				Start:  p.Start.Seconds(),
+				End:    p.End.Seconds(),
+			})
+		}
+		out = append(out, jw)
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(map[string]any{"words": out})
+}
+
+// WriteSRT writes SubRip subtitles, grouping words into short cues.
+func WriteSRT(w io.Writer, words []Word) error {
+	bw := bufio.NewWriter(w)
+	for i, cue := range Cues(words) {
+		fmt.Fprintf(bw, "%d\n%s --> %s\n%s\n\n", i+1,
+			formatTimestamp(cue.Start, ","), formatTimestamp(cue.End, ","), cue.Text)
+	}
+	return bw.Flush()
+}
+
+// WriteVTT writes WebVTT subtitles, grouping words into short cues.
+func WriteVTT(w io.Writer, words []Word) error {
+	bw := bufio.NewWriter(w)
+	bw.WriteString("WEBVTT\n\n")
+	for _, cue := range Cues(words) {
+		fmt.Fprintf(bw, "%s --> %s\n%s\n\n",
+			formatTimestamp(cue.Start, "."), formatTimestamp(cue.End, "."), cue.Text)
+	}
+	return bw.Flush()
+}
+
+// Cue is a subtitle line.
+type Cue struct {
+	Text  string
+	Start time.Duration
+	End   time.Duration
+}
+
+// Cues groups words into subtitle lines, breaking after sentence-ending
+// punctuation or when a line gets too long.
+func Cues(words []Word) []Cue {
+	var cues []Cue
+	var current []string
+	var start time.Duration
+	for i, word := range words {
+		if len(current) == 0 {
+			start = word.Start
+		}
+		current = append(current, word.Text)
+
+		last := i == len(words)-1
+		if last || len(current) >= maxCueWords || word.End-start >= maxCueDuration ||
+			strings.ContainsAny(word.Text, ".!?;") {
+			cues = append(cues, Cue{
+				Text:  strings.Join(current, " "),
+				Start: start,
+				End:   word.End,
+			})
+			current = current[:0]
+		}
+	}
+	return cues
+}
+
+func formatTimestamp(d time.Duration, sep string) string {
+	ms := d.Milliseconds()
+	return fmt.Sprintf("%02d:%02d:%02d%s%03d", ms/3600000, ms/60000%60, ms/1000%60, sep, ms%1000)
+}
@@ -0,0 +1,41 @@
+package audio
+
+import "time"
+
+func NewSilence(d time.Duration, sampleRate int) *Audio {
+	n := int(d.Seconds() * float64(sampleRate))
+	if n < 0 {
+		n = 0
+	}
+	return &Audio{
+		Samples:    make([]float32, n),
+		SampleRate: sampleRate,
+	}
+}
+
+func Concat(silence time.Duration, parts ...*Audio) *Audio {
+	if len(parts) == 0 {
+		return NewAudio(nil)
+	}
+
+	sampleRate := parts[0].SampleRate
+	gap := NewSilence(silence, sampleRate).Samples
+
+	total := len(gap) * (len(parts) - 1)
+	for _, p := range parts {
+		total += len(p.Samples)
+	}
+
+	samples := make([]float32, 0, total)
+	for i, p := range parts {
+		if i > 0 {
+			samples = append(samples, gap...)
+		}
+		samples = append(samples, p.Samples...)
+	}
+
+	return &Audio{
+		Samples:    samples,
+		SampleRate: sampleRate,
+	}
+}
@@ -0,0 +1,88 @@
+package audio
+
+import (
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	FormatWAV  = "wav"
+	FormatPCM  = "pcm"
+	FormatULaw = "ulaw"
+	FormatALaw = "alaw"
+	FormatFLAC = "flac"
+)
+
+var Formats = []string{FormatWAV, FormatPCM, FormatULaw, FormatALaw, FormatFLAC}
+
+// FormatFromPath picks an output format from a file extension, defaulting
+// to WAV.
+func FormatFromPath(path string) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".pcm", ".raw":
+		return FormatPCM
+	case ".ulaw", ".ul", ".mulaw", ".mu":
+		return FormatULaw
+	case ".alaw", ".al":
+		return FormatALaw
+	case ".flac":
+		return FormatFLAC
+	default:
+		return FormatWAV
+	}
+}
+
+// FormatSampleRate returns the rate a format must be written at, or 0 if
+// any rate will do. G.711, raw or in a WAV container, is always 8 kHz.
+func FormatSampleRate(format string, opts WriteOptions) int {
+	format, opts, err := resolveFormat(format, opts)
+	if err != nil {
+		return 0
+	}
+	if format == FormatULaw || format == FormatALaw ||
+		opts.SampleFormat == SampleULaw || opts.SampleFormat == SampleALaw {
+		return G711SampleRate
+	}
+	return 0
+}
+
+// Encode writes a in the given output format. The raw G.711 formats imply
+// their sample format; the others use opts.SampleFormat.
+func (a *Audio) Encode(w io.Writer, format string, opts WriteOptions) error {
+	format, opts, err := resolveFormat(format, opts)
+	if err != nil {
+		return err
+	}
+	switch format {
+	case FormatWAV:
+		return a.WriteWAVWith(w, opts)
+	case FormatFLAC:
+		return a.WriteFLAC(w, opts)
+	default:
+		return a.WritePCMWith(w, opts)
+	}
+}
+
+func resolveFormat(format string, opts WriteOptions) (string, WriteOptions, error) {
+	switch strings.ToLower(format) {
+	case "", FormatWAV:
+		return FormatWAV, opts, nil
+	case FormatPCM:
+		return FormatPCM, opts, nil
+	case FormatFLAC:
+		if opts.SampleFormat != SampleS16 && opts.SampleFormat != SampleS24 {
+			return "", opts, fmt.Errorf("FLAC supports s16 and s24 samples, not %s", opts.SampleFormat)
+		}
+		return FormatFLAC, opts, nil
+	case FormatULaw:
+		opts.SampleFormat = SampleULaw
+		return FormatULaw, opts, nil
+	case FormatALaw:
+		opts.SampleFormat = SampleALaw
+		return FormatALaw, opts, nil
+	default:
+		return "", opts, fmt.Errorf("unsupported output format: %s", format)
+	}
+}
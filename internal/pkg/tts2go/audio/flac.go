@@ -0,0 +1,426 @@
+package audio
+
+import (
+	"bytes"
+	"crypto/md5"
+	"encoding/binary"
+	"fmt"
+	"hash"
+	"io"
+	"math"
+	"math/bits"
+)
+
+// FLACBlockSize is the number of samples per FLAC frame.
+const FLACBlockSize = 4096
+
+const (
+	flacMaxFixedOrder     = 4
+	flacMaxPartitionOrder = 8
+	flacStreamInfoSize    = 34
+)
+
+// WriteFLAC encodes a as a FLAC stream with s16 or s24 samples.
+func (a *Audio) WriteFLAC(w io.Writer, opts WriteOptions) error {
+	var frames bytes.Buffer
+	fw, err := newFLACWriter(&frames, a.SampleRate, opts)
+	if err != nil {
+		return err
+	}
+	if err := fw.write(a.Samples); err != nil {
+		return err
+	}
+	if err := fw.flush(); err != nil {
+		return err
+	}
+
+	if _, err := w.Write(fw.streamInfo(true)); err != nil {
+		return err
+	}
+	_, err = frames.WriteTo(w)
+	return err
+}
+
+// flacWriter is a minimal FLAC encoder: mono, fixed block size, and for
+// each block the cheapest of a constant, verbatim or fixed-predictor
+// subframe with partitioned Rice residuals. That covers most of the gain
+// of a full LPC encoder on speech while staying small.
+type flacWriter struct {
+	w          io.Writer
+	encoder    *sampleEncoder
+	bps        int
+	sampleRate int
+
+	pending  []int32
+	frame    uint64
+	total    uint64
+	md5      hash.Hash
+	minFrame int
+	maxFrame int
+}
+
+func newFLACWriter(w io.Writer, sampleRate int, opts WriteOptions) (*flacWriter, error) {
+	if opts.SampleFormat != SampleS16 && opts.SampleFormat != SampleS24 {
+		return nil, fmt.Errorf("FLAC supports s16 and s24 samples, not %s", opts.SampleFormat)
+	}
+	return &flacWriter{
+		w:          w,
+		encoder:    newSampleEncoder(opts),
+		bps:        opts.SampleFormat.BitsPerSample(),
+		sampleRate: sampleRate,
+		md5:        md5.New(),
+		minFrame:   math.MaxInt,
+	}, nil
+}
+
+// streamInfo returns the "fLaC" marker and STREAMINFO block. Before the
+// stream is finished the total length and MD5 are left as zero, which
+// decoders read as unknown.
+func (f *flacWriter) streamInfo(final bool) []byte {
+	b := make([]byte, 0, 4+4+flacStreamInfoSize)
+	b = append(b, "fLaC"...)
+	b = append(b, 0x80, 0, 0, flacStreamInfoSize) // last metadata block, type 0
+
+	minFrame, maxFrame := 0, 0
+	var total uint64
+	var sum []byte
+	if final {
+		total = f.total
+		sum = f.md5.Sum(nil)
+		if f.maxFrame > 0 {
+			minFrame, maxFrame = f.minFrame, f.maxFrame
+		}
+	} else {
+		sum = make([]byte, md5.Size)
+	}
+
+	bw := &bitWriter{buf: b}
+	bw.write(FLACBlockSize, 16)
+	bw.write(FLACBlockSize, 16)
+	bw.write(uint64(minFrame), 24)
+	bw.write(uint64(maxFrame), 24)
+	bw.write(uint64(f.sampleRate), 20)
+	bw.write(NumChannels-1, 3)
+	bw.write(uint64(f.bps-1), 5)
+	bw.write(total>>32, 4)
+	bw.write(total&0xFFFFFFFF, 32)
+	bw.buf = append(bw.buf, sum...)
+	return bw.buf
+}
+
+func (f *flacWriter) write(samples []float32) error {
+	full := float64(int64(1)<<(f.bps-1) - 1)
+	var raw [4]byte
+	for _, s := range samples {
+		v := int32(f.encoder.quantize(s, full))
+		f.pending = append(f.pending, v)
+		binary.LittleEndian.PutUint32(raw[:], uint32(v))
+		f.md5.Write(raw[:f.bps/8])
+	}
+	f.total += uint64(len(samples))
+
+	for len(f.pending) >= FLACBlockSize {
+		if err := f.writeFrame(f.pending[:FLACBlockSize]); err != nil {
+			return err
+		}
+		f.pending = append(f.pending[:0], f.pending[FLACBlockSize:]...)
+	}
+	return nil
+}
+
+// flush writes any partial final block.
+func (f *flacWriter) flush() error {
+	if len(f.pending) == 0 {
+		return nil
+	}
+	err := f.writeFrame(f.pending)
+	f.pending = f.pending[:0]
+	return err
+}
+
+func (f *flacWriter) writeFrame(block []int32) error {
+	bw := &bitWriter{}
+
+	bw.write(0x3FFE, 14) // sync
+	bw.write(0, 1)
+	bw.write(0, 1) // fixed block size
+	if len(block) == FLACBlockSize {
+		bw.write(0xC, 4) // 256 * 2^(12-8)
+	} else {
+		bw.write(0x7, 4) // 16-bit size at end of header
+	}
+	bw.write(0, 4) // sample rate from STREAMINFO
+	bw.write(0, 4) // mono
+	if f.bps == 24 {
+		bw.write(0x6, 3)
+	} else {
+		bw.write(0x4, 3)
+	}
+	bw.write(0, 1)
+	bw.buf = appendUTF8Uint(bw.buf, f.frame)
+	if len(block) != FLACBlockSize {
+		bw.write(uint64(len(block)-1), 16)
+	}
+	bw.buf = append(bw.buf, crc8(bw.buf))
+
+	writeSubframe(bw, block, f.bps)
+	bw.align()
+	bw.buf = binary.BigEndian.AppendUint16(bw.buf, crc16(bw.buf))
+
+	f.frame++
+	f.minFrame = min(f.minFrame, len(bw.buf))
+	f.maxFrame = max(f.maxFrame, len(bw.buf))
+	_, err := f.w.Write(bw.buf)
+	return err
+}
+
+func writeSubframe(bw *bitWriter, block []int32, bps int) {
+	constant := true
+	for _, v := range block[1:] {
+		if v != block[0] {
+			constant = false
+			break
+		}
+	}
+	if constant {
+		bw.write(0, 8) // pad, type 000000, no wasted bits
+		bw.write(uint64(uint32(block[0])), uint(bps))
+		return
+	}
+
+	order, residual := bestFixedOrder(block)
+	plan := planRice(residual, len(block), order)
+	verbatimBits := len(block) * bps
+	if order < len(block) && order*bps+plan.bits < verbatimBits {
+		bw.write(uint64(0x08|order)<<1, 8) // pad, type 001xxx, no wasted bits
+		for _, v := range block[:order] {
+			bw.write(uint64(uint32(v)), uint(bps))
+		}
+		plan.encode(bw, residual)
+		return
+	}
+
+	bw.write(0x1<<1, 8) // pad, type 000001, no wasted bits
+	for _, v := range block {
+		bw.write(uint64(uint32(v)), uint(bps))
+	}
+}
+
+// bestFixedOrder picks the fixed predictor whose residual has the smallest
+// absolute sum and returns the residual for samples order..len(block).
+func bestFixedOrder(block []int32) (int, []int64) {
+	maxOrder := min(flacMaxFixedOrder, len(block)-1)
+	best, bestSum := 0, uint64(math.MaxUint64)
+	for order := 0; order <= maxOrder; order++ {
+		var sum uint64
+		for i := order; i < len(block); i++ {
+			r := fixedResidual(block, i, order)
+			if r < 0 {
+				r = -r
+			}
+			sum += uint64(r)
+		}
+		if sum < bestSum {
+			best, bestSum = order, sum
+		}
+	}
+
+	residual := make([]int64, len(block)-best)
+	for i := best; i < len(block); i++ {
+		residual[i-best] = fixedResidual(block, i, best)
+	}
+	return best, residual
+}
+
+func fixedResidual(x []int32, i, order int) int64 {
+	switch order {
+	case 0:
+		return int64(x[i])
+	case 1:
+		return int64(x[i]) - int64(x[i-1])
+	case 2:
+		return int64(x[i]) - 2*int64(x[i-1]) + int64(x[i-2])
+	case 3:
+		return int64(x[i]) - 3*int64(x[i-1]) + 3*int64(x[i-2]) - int64(x[i-3])
+	default:
+		return int64(x[i]) - 4*int64(x[i-1]) + 6*int64(x[i-2]) - 4*int64(x[i-3]) + int64(x[i-4])
+	}
+}
+
+type ricePlan struct {
+	method         int // 0: 4-bit parameters, 1: 5-bit parameters
+	order          int
+	partitionOrder int
+	params         []int
+	bits           int
+}
+
+// planRice chooses the partition order and per-partition Rice parameters
+// that minimize the encoded residual size.
+func planRice(residual []int64, blockSize, order int) ricePlan {
+	best := ricePlan{bits: math.MaxInt}
+	for p := 0; p <= flacMaxPartitionOrder; p++ {
+		parts := 1 << p
+		if blockSize%parts != 0 || blockSize/parts <= order {
+			break
+		}
+		plan := ricePlan{order: order, partitionOrder: p, params: make([]int, parts), bits: 6}
+		start := 0
+		for i := range parts {
+			n := blockSize / parts
+			if i == 0 {
+				n -= order
+			}
+			k, cost := riceParam(residual[start : start+n])
+			plan.params[i] = k
+			if k > 14 {
+				plan.method = 1
+			}
+			plan.bits += cost
+			start += n
+		}
+		paramBits := 4
+		if plan.method == 1 {
+			paramBits = 5
+		}
+		plan.bits += parts * paramBits
+		if plan.bits < best.bits {
+			best = plan
+		}
+	}
+	return best
+}
+
+func riceParam(residual []int64) (int, int) {
+	if len(residual) == 0 {
+		return 0, 0
+	}
+	var sum uint64
+	for _, r := range residual {
+		sum += zigzag(r)
+	}
+	mean := sum / uint64(len(residual))
+	guess := max(bits.Len64(mean)-1, 0)
+
+	bestK, bestCost := 0, math.MaxInt
+	for k := max(guess-1, 0); k <= min(guess+1, 30); k++ {
+		cost := len(residual) * (k + 1)
+		for _, r := range residual {
+			cost += int(zigzag(r) >> k)
+		}
+		if cost < bestCost {
+			bestK, bestCost = k, cost
+		}
+	}
+	return bestK, bestCost
+}
+
+func (p ricePlan) encode(bw *bitWriter, residual []int64) {
+	paramBits := uint(4)
+	if p.method == 1 {
+		paramBits = 5
+	}
+	bw.write(uint64(p.method), 2)
+	bw.write(uint64(p.partitionOrder), 4)
+
+	n := (len(residual) + p.order) >> p.partitionOrder
+	start := 0
+	for i, k := range p.params {
+		count := n
+		if i == 0 {
+			count -= p.order
+		}
+		bw.write(uint64(k), paramBits)
+		for _, r := range residual[start : start+count] {
+			u := zigzag(r)
+			bw.unary(u >> k)
+			bw.write(u&(1<<k-1), uint(k))
+		}
+		start += count
+	}
+}
+
+func zigzag(r int64) uint64 {
+	return uint64(r<<1) ^ uint64(r>>63)
+}
+
+func appendUTF8Uint(b []byte, v uint64) []byte {
+	if v < 0x80 {
+		return append(b, byte(v))
+	}
+	n := 2
+	for n < 7 && v >= 1<<(6*(n-1)+7-n) {
+		n++
+	}
+	b = append(b, byte(0xFF<<(8-n))|byte(v>>(6*(n-1))))
+	for i := n - 2; i >= 0; i-- {
+		b = append(b, 0x80|byte(v>>(6*i))&0x3F)
+	}
+	return b
+}
+
+func crc8(b []byte) byte {
+	var crc byte
+	for _, c := range b {
+		crc ^= c
+		for range 8 {
+			if crc&0x80 != 0 {
+				crc = crc<<1 ^ 0x07
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+	return crc
+}
+
+func crc16(b []byte) uint16 {
+	var crc uint16
+	for _, c := range b {
+		crc ^= uint16(c) << 8
+		for range 8 {
+			if crc&0x8000 != 0 {
+				crc = crc<<1 ^ 0x8005
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+	return crc
+}
+
+// bitWriter appends big-endian bit fields to a byte slice.
+type bitWriter struct {
+	buf []byte
+	acc uint64
+	n   uint
+}
+
+func (b *bitWriter) write(v uint64, width uint) {
+	for width > 32 {
+		b.write(v>>32, width-32)
+		v &= 0xFFFFFFFF
+		width = 32
+	}
+	b.acc = b.acc<<width | v&(1<<width-1)
+	b.n += width
+	for b.n >= 8 {
+		b.n -= 8
+		b.buf = append(b.buf, byte(b.acc>>b.n))
+	}
+	b.acc &= 1<<b.n - 1
+}
+
+func (b *bitWriter) unary(q uint64) {
+	for q >= 32 {
+		b.write(0, 32)
+		q -= 32
+	}
+	b.write(1, uint(q)+1)
+}
+
+func (b *bitWriter) align() {
+	if b.n > 0 {
+		b.write(0, 8-b.n)
+	}
+}
@@ -0,0 +1,338 @@
+package audio
+
+import (
+	"bytes"
+	"crypto/md5"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// flacStream is what decodeFLAC reads back from an encoded stream.
+type flacStream struct {
+	sampleRate int
+	bps        int
+	total      uint64
+	md5        []byte
+	minFrame   int
+	maxFrame   int
+	frameSizes []int
+	samples    []int32
+}
+
+type bitReader struct {
+	buf []byte
+	pos uint // in bits
+}
+
+func (r *bitReader) read(width uint) uint64 {
+	var v uint64
+	for range width {
+		bit := r.buf[r.pos/8] >> (7 - r.pos%8) & 1
+		v = v<<1 | uint64(bit)
+		r.pos++
+	}
+	return v
+}
+
+func (r *bitReader) signed(width uint) int64 {
+	v := r.read(width)
+	return int64(v<<(64-width)) >> (64 - width)
+}
+
+func (r *bitReader) unary() uint64 {
+	var q uint64
+	for r.read(1) == 0 {
+		q++
+	}
+	return q
+}
+
+func (r *bitReader) align() {
+	r.pos = (r.pos + 7) &^ 7
+}
+
+// decodeFLAC decodes the subset of FLAC the encoder produces: a single
+// STREAMINFO block, mono fixed-size frames, and constant, verbatim or
+// fixed-predictor subframes with Rice residuals. It checks every CRC.
+func decodeFLAC(t *testing.T, data []byte) flacStream {
+	t.Helper()
+	if string(data[:4]) != "fLaC" {
+		t.Fatalf("missing fLaC marker")
+	}
+	if data[4] != 0x80 || binary.BigEndian.Uint32(data[4:])&0xFFFFFF != flacStreamInfoSize {
+		t.Fatalf("expected a single, last STREAMINFO block")
+	}
+
+	r := &bitReader{buf: data, pos: 8 * 8}
+	if minBlock, maxBlock := r.read(16), r.read(16); minBlock != FLACBlockSize || maxBlock != FLACBlockSize {
+		t.Errorf("block size = %d..%d, want %d", minBlock, maxBlock, FLACBlockSize)
+	}
+	var s flacStream
+	s.minFrame = int(r.read(24))
+	s.maxFrame = int(r.read(24))
+	s.sampleRate = int(r.read(20))
+	if channels := r.read(3) + 1; channels != NumChannels {
+		t.Fatalf("channels = %d, want %d", channels, NumChannels)
+	}
+	s.bps = int(r.read(5) + 1)
+	s.total = r.read(36)
+	s.md5 = data[r.pos/8 : r.pos/8+md5.Size]
+	r.pos += md5.Size * 8
+
+	for frame := uint64(0); r.pos/8 < uint(len(data)); frame++ {
+		start := r.pos / 8
+		if sync := r.read(14); sync != 0x3FFE {
+			t.Fatalf("frame %d: sync = %#x", frame, sync)
+		}
+		if r.read(1) != 0 || r.read(1) != 0 {
+			t.Fatalf("frame %d: reserved or variable block size bit set", frame)
+		}
+		sizeCode, rateCode, channelCode, bpsCode := r.read(4), r.read(4), r.read(4), r.read(3)
+		r.read(1)
+		if rateCode != 0 || channelCode != 0 {
+			t.Fatalf("frame %d: sample rate code %d, channel code %d", frame, rateCode, channelCode)
+		}
+		if want := map[int]uint64{16: 4, 24: 6}[s.bps]; bpsCode != want {
+			t.Fatalf("frame %d: bits per sample code %d, want %d", frame, bpsCode, want)
+		}
+
+		// UTF-8 coded frame number.
+		first := r.read(8)
+		n := 0
+		for first&(0x80>>n) != 0 {
+			n++
+		}
+		number := first & (0x7F >> n)
+		for range max(n-1, 0) {
+			number = number<<6 | r.read(8)&0x3F
+		}
+		if number != frame {
+			t.Fatalf("frame number = %d, want %d", number, frame)
+		}
+
+		var size int
+		switch sizeCode {
+		case 0xC:
+			size = FLACBlockSize
+		case 0x7:
+			size = int(r.read(16)) + 1
+		default:
+			t.Fatalf("frame %d: block size code %#x", frame, sizeCode)
+		}
+		if crc := byte(r.read(8)); crc != crc8(data[start:r.pos/8-1]) {
+			t.Fatalf("frame %d: header CRC mismatch", frame)
+		}
+
+		s.samples = append(s.samples, decodeSubframe(t, r, size, s.bps)...)
+		r.align()
+		end := r.pos / 8
+		if crc := uint16(r.read(16)); crc != crc16(data[start:end]) {
+			t.Fatalf("frame %d: CRC mismatch", frame)
+		}
+		s.frameSizes = append(s.frameSizes, int(end-start)+2)
+	}
+	return s
+}
+
+func decodeSubframe(t *testing.T, r *bitReader, size, bps int) []int32 {
+	t.Helper()
+	if r.read(1) != 0 {
+		t.Fatalf("subframe padding bit set")
+	}
+	kind := r.read(6)
+	if r.read(1) != 0 {
+		t.Fatalf("unexpected wasted bits")
+	}
+
+	out := make([]int32, size)
+	switch {
+	case kind == 0:
+		v := int32(r.signed(uint(bps)))
+		for i := range out {
+			out[i] = v
+		}
+	case kind == 1:
+		for i := range out {
+			out[i] = int32(r.signed(uint(bps)))
+		}
+	case kind >= 8 && kind <= 12:
+		order := int(kind - 8)
+		for i := range order {
+			out[i] = int32(r.signed(uint(bps)))
+		}
+		method := r.read(2)
+		paramBits, escape := uint(4), uint64(15)
+		if method == 1 {
+			paramBits, escape = 5, 31
+		}
+		partitions := 1 << r.read(4)
+		i := order
+		for p := range partitions {
+			k := r.read(paramBits)
+			if k == escape {
+				t.Fatalf("unexpected escaped partition")
+			}
+			count := size / partitions
+			if p == 0 {
+				count -= order
+			}
+			for range count {
+				u := r.unary()<<k | r.read(uint(k))
+				res := int64(u>>1) ^ -int64(u&1)
+				out[i] = int32(res + fixedPrediction(out, i, order))
+				i++
+			}
+		}
+	default:
+		t.Fatalf("unexpected subframe type %d", kind)
+	}
+	return out
+}
+
+func fixedPrediction(x []int32, i, order int) int64 {
+	switch order {
+	case 0:
+		return 0
+	case 1:
+		return int64(x[i-1])
+	case 2:
+		return 2*int64(x[i-1]) - int64(x[i-2])
+	case 3:
+		return 3*int64(x[i-1]) - 3*int64(x[i-2]) + int64(x[i-3])
+	default:
+		return 4*int64(x[i-1]) - 6*int64(x[i-2]) + 4*int64(x[i-3]) - int64(x[i-4])
+	}
+}
+
+// flacInput returns n samples that exercise every subframe type: a sine for
+// the fixed predictors, silence for constant blocks and full-scale noise for
+// verbatim ones.
+func flacInput(n int) []float32 {
+	rng := rand.New(rand.NewSource(1))
+	samples := make([]float32, n)
+	for i := range samples {
+		switch (i / FLACBlockSize) % 3 {
+		case 0:
+			samples[i] = float32(math.Sin(float64(i)*0.03)) * 0.8
+		case 1:
+			samples[i] = 0
+		default:
+			samples[i] = rng.Float32()*2 - 1
+		}
+	}
+	return samples
+}
+
+// quantizedPCM returns the samples as the encoder quantizes them, and the MD5
+// of their little-endian bytes as FLAC defines it.
+func quantizedPCM(samples []float32, format SampleFormat) ([]int32, []byte) {
+	bps := format.BitsPerSample()
+	full := float64(int64(1)<<(bps-1) - 1)
+	enc := newSampleEncoder(WriteOptions{SampleFormat: format})
+	h := md5.New()
+	out := make([]int32, len(samples))
+	var raw [4]byte
+	for i, s := range samples {
+		out[i] = int32(enc.quantize(s, full))
+		binary.LittleEndian.PutUint32(raw[:], uint32(out[i]))
+		h.Write(raw[:bps/8])
+	}
+	return out, h.Sum(nil)
+}
+
+func checkFLAC(t *testing.T, data []byte, samples []float32, format SampleFormat) {
+	t.Helper()
+	s := decodeFLAC(t, data)
+	want, sum := quantizedPCM(samples, format)
+
+	if s.sampleRate != SampleRate || s.bps != format.BitsPerSample() {
+		t.Errorf("STREAMINFO: %d Hz, %d bits; want %d Hz, %d bits", s.sampleRate, s.bps, SampleRate, format.BitsPerSample())
+	}
+	if s.total != uint64(len(samples)) {
+		t.Errorf("STREAMINFO total samples = %d, want %d", s.total, len(samples))
+	}
+	if !bytes.Equal(s.md5, sum) {
+		t.Errorf("STREAMINFO MD5 = %x, want %x", s.md5, sum)
+	}
+	if len(s.frameSizes) > 0 {
+		minFrame, maxFrame := math.MaxInt, 0
+		for _, n := range s.frameSizes {
+			minFrame, maxFrame = min(minFrame, n), max(maxFrame, n)
+		}
+		if s.minFrame != minFrame || s.maxFrame != maxFrame {
+			t.Errorf("STREAMINFO frame size = %d..%d, want %d..%d", s.minFrame, s.maxFrame, minFrame, maxFrame)
+		}
+	}
+
+	if len(s.samples) != len(want) {
+		t.Fatalf("decoded %d samples, want %d", len(s.samples), len(want))
+	}
+	for i := range want {
+		if s.samples[i] != want[i] {
+			t.Fatalf("sample %d = %d, want %d", i, s.samples[i], want[i])
+		}
+	}
+}
+
+func TestWriteFLACRoundTrip(t *testing.T) {
+	for _, format := range []SampleFormat{SampleS16, SampleS24} {
+		for _, n := range []int{1, 2, FLACBlockSize, FLACBlockSize + 1, 3*FLACBlockSize + 100} {
+			t.Run(fmt.Sprintf("%s/%d", format, n), func(t *testing.T) {
+				samples := flacInput(n)
+				var buf bytes.Buffer
+				a := &Audio{Samples: samples, SampleRate: SampleRate}
+				if err := a.WriteFLAC(&buf, WriteOptions{SampleFormat: format}); err != nil {
+					t.Fatal(err)
+				}
+				checkFLAC(t, buf.Bytes(), samples, format)
+			})
+		}
+	}
+}
+
+func TestStreamWriterFLAC(t *testing.T) {
+	samples := flacInput(3*FLACBlockSize + 100)
+	opts := WriteOptions{SampleFormat: SampleS16}
+
+	var whole bytes.Buffer
+	if err := (&Audio{Samples: samples, SampleRate: SampleRate}).WriteFLAC(&whole, opts); err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(t.TempDir(), "out.flac")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	sw, err := NewStreamWriter(f, FormatFLAC, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rng := rand.New(rand.NewSource(2))
+	for off := 0; off < len(samples); {
+		n := min(1+rng.Intn(3000), len(samples)-off)
+		if err := sw.Write(&Audio{Samples: samples[off : off+n], SampleRate: SampleRate}); err != nil {
+			t.Fatal(err)
+		}
+		off += n
+	}
+	if err := sw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkFLAC(t, data, samples, opts.SampleFormat)
+	if !bytes.Equal(data, whole.Bytes()) {
+		t.Errorf("streamed FLAC differs from WriteFLAC output")
+	}
+}
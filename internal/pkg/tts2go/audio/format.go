@@ -0,0 +1,229 @@
+package audio
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"math/rand/v2"
+	"strings"
+)
+
+// SampleFormat is the on-disk encoding of each sample. The zero value is
+// 16-bit signed integer PCM.
+type SampleFormat int
+
+const (
+	SampleS16 SampleFormat = iota
+	SampleS24
+	SampleS32
+	SampleF32
+	SampleULaw
+	SampleALaw
+)
+
+var sampleFormatNames = map[SampleFormat]string{
+	SampleS16:  "s16",
+	SampleS24:  "s24",
+	SampleS32:  "s32",
+	SampleF32:  "f32",
+	SampleULaw: "ulaw",
+	SampleALaw: "alaw",
+}
+
+func ParseSampleFormat(s string) (SampleFormat, error) {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "", "s16", "16":
+		return SampleS16, nil
+	case "s24", "24":
+		return SampleS24, nil
+	case "s32", "32":
+		return SampleS32, nil
+	case "f32", "float", "float32":
+		return SampleF32, nil
+	case "ulaw", "mulaw", "mu-law":
+		return SampleULaw, nil
+	case "alaw", "a-law":
+		return SampleALaw, nil
+	default:
+		return 0, fmt.Errorf("unknown sample format %q (want s16, s24, s32, f32, ulaw or alaw)", s)
+	}
+}
+
+func (f SampleFormat) String() string {
+	if name, ok := sampleFormatNames[f]; ok {
+		return name
+	}
+	return fmt.Sprintf("SampleFormat(%d)", int(f))
+}
+
+func (f SampleFormat) BitsPerSample() int {
+	switch f {
+	case SampleULaw, SampleALaw:
+		return 8
+	case SampleS24:
+		return 24
+	case SampleS32, SampleF32:
+		return 32
+	default:
+		return 16
+	}
+}
+
+func (f SampleFormat) BytesPerSample() int {
+	return f.BitsPerSample() / 8
+}
+
+// WriteOptions controls how float samples are quantized on output.
+type WriteOptions struct {
+	SampleFormat SampleFormat
+	// Dither adds triangular (TPDF) dither of one LSB before quantizing to
+	// an integer format; G.711 is dithered at 16 bits before companding.
+	// It has no effect on f32 output.
+	Dither bool
+}
+
+const (
+	wavFormatPCM        = 0x0001
+	wavFormatIEEEFloat  = 0x0003
+	wavFormatALaw       = 0x0006
+	wavFormatULaw       = 0x0007
+	wavFormatExtensible = 0xFFFE
+)
+
+// KSDATAFORMAT_SUBTYPE_PCM, minus the leading format tag.
+var pcmSubFormatGUID = [14]byte{
+	0x00, 0x00, 0x00, 0x00, 0x10, 0x00, 0x80, 0x00, 0x00, 0xAA, 0x00, 0x38, 0x9B, 0x71,
+}
+
+// buildWAVHeader returns a RIFF/WAVE header for dataSize bytes of samples.
+// 16-bit output uses plain WAVE_FORMAT_PCM, 24/32-bit integers use
+// WAVE_FORMAT_EXTENSIBLE, and float and G.711 use their own format tags
+// with a fact chunk. An odd dataSize is followed by a pad byte, which the
+// RIFF size counts and the data chunk size does not.
+func buildWAVHeader(sampleRate int, format SampleFormat, dataSize uint32) []byte {
+	var fmtChunk bytes.Buffer
+	bits := format.BitsPerSample()
+	blockAlign := NumChannels * format.BytesPerSample()
+
+	tag := uint16(wavFormatPCM)
+	switch format {
+	case SampleS24, SampleS32:
+		tag = wavFormatExtensible
+	case SampleF32:
+		tag = wavFormatIEEEFloat
+	case SampleALaw:
+		tag = wavFormatALaw
+	case SampleULaw:
+		tag = wavFormatULaw
+	}
+
+	binary.Write(&fmtChunk, binary.LittleEndian, tag)
+	binary.Write(&fmtChunk, binary.LittleEndian, uint16(NumChannels))
+	binary.Write(&fmtChunk, binary.LittleEndian, uint32(sampleRate))
+	binary.Write(&fmtChunk, binary.LittleEndian, uint32(sampleRate*blockAlign))
+	binary.Write(&fmtChunk, binary.LittleEndian, uint16(blockAlign))
+	binary.Write(&fmtChunk, binary.LittleEndian, uint16(bits))
+	switch tag {
+	case wavFormatExtensible:
+		binary.Write(&fmtChunk, binary.LittleEndian, uint16(22))
+		binary.Write(&fmtChunk, binary.LittleEndian, uint16(bits))
+		binary.Write(&fmtChunk, binary.LittleEndian, uint32(0x4)) // front center
+		binary.Write(&fmtChunk, binary.LittleEndian, uint16(wavFormatPCM))
+		fmtChunk.Write(pcmSubFormatGUID[:])
+	case wavFormatIEEEFloat, wavFormatALaw, wavFormatULaw:
+		binary.Write(&fmtChunk, binary.LittleEndian, uint16(0))
+	}
+
+	var h bytes.Buffer
+	h.WriteString("RIFF")
+	binary.Write(&h, binary.LittleEndian, uint32(0)) // patched below
+	h.WriteString("WAVE")
+	h.WriteString("fmt ")
+	binary.Write(&h, binary.LittleEndian, uint32(fmtChunk.Len()))
+	h.Write(fmtChunk.Bytes())
+
+	if tag != wavFormatPCM && tag != wavFormatExtensible {
+		h.WriteString("fact")
+		binary.Write(&h, binary.LittleEndian, uint32(4))
+		binary.Write(&h, binary.LittleEndian, dataSize/uint32(blockAlign))
+	}
+
+	h.WriteString("data")
+	binary.Write(&h, binary.LittleEndian, dataSize)
+
+	header := h.Bytes()
+	riffSize := uint64(len(header)-8) + uint64(dataSize) + uint64(dataSize&1)
+	if riffSize > math.MaxUint32 {
+		riffSize = math.MaxUint32
+	}
+	binary.LittleEndian.PutUint32(header[4:], uint32(riffSize))
+	return header
+}
+
+// sampleEncoder quantizes float samples into a sample format.
+type sampleEncoder struct {
+	format SampleFormat
+	dither bool
+	rng    *rand.Rand
+	buf    []byte
+}
+
+func newSampleEncoder(opts WriteOptions) *sampleEncoder {
+	e := &sampleEncoder{
+		format: opts.SampleFormat,
+		dither: opts.Dither && opts.SampleFormat != SampleF32,
+	}
+	if e.dither {
+		e.rng = rand.New(rand.NewPCG(rand.Uint64(), rand.Uint64()))
+	}
+	return e
+}
+
+func (e *sampleEncoder) write(w io.Writer, samples []float32) error {
+	size := e.format.BytesPerSample()
+	need := len(samples) * size
+	if cap(e.buf) < need {
+		e.buf = make([]byte, need)
+	}
+	buf := e.buf[:need]
+
+	for i, s := range samples {
+		out := buf[i*size:]
+		switch e.format {
+		case SampleF32:
+			binary.LittleEndian.PutUint32(out, math.Float32bits(s))
+		case SampleS24:
+			v := e.quantize(s, 1<<23-1)
+			out[0] = byte(v)
+			out[1] = byte(v >> 8)
+			out[2] = byte(v >> 16)
+		case SampleS32:
+			binary.LittleEndian.PutUint32(out, uint32(int32(e.quantize(s, math.MaxInt32))))
+		case SampleULaw:
+			out[0] = LinearToULaw(int16(e.quantize(s, math.MaxInt16)))
+		case SampleALaw:
+			out[0] = LinearToALaw(int16(e.quantize(s, math.MaxInt16)))
+		default:
+			binary.LittleEndian.PutUint16(out, uint16(int16(e.quantize(s, math.MaxInt16))))
+		}
+	}
+
+	_, err := w.Write(buf)
+	return err
+}
+
+func (e *sampleEncoder) quantize(s float32, full float64) int64 {
+	v := float64(s) * full
+	if e.dither {
+		v += e.rng.Float64() - e.rng.Float64()
+	}
+	v = math.Round(v)
+	if v > full {
+		v = full
+	} else if v < -full {
+		v = -full
+	}
+	return int64(v)
+}
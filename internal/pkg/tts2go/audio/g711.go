@@ -0,0 +1,58 @@
+package audio
+
+// G711SampleRate is the rate G.711 is defined at; telephony peers expect
+// nothing else.
+const G711SampleRate = 8000
+
+const (
+	ulawBias = 0x21
+	ulawClip = 8159
+)
+
+// LinearToULaw encodes a 16-bit linear sample as G.711 mu-law.
+func LinearToULaw(sample int16) byte {
+	s := int32(sample) >> 2 // mu-law works on 14-bit magnitudes
+	mask := byte(0xFF)
+	if s < 0 {
+		s = -s
+		mask = 0x7F
+	}
+	if s > ulawClip {
+		s = ulawClip
+	}
+	s += ulawBias
+
+	exponent := byte(0)
+	for v := s >> 6; v > 0; v >>= 1 {
+		exponent++
+	}
+	if exponent > 7 {
+		return 0x7F ^ mask
+	}
+	return (exponent<<4 | byte(s>>(exponent+1))&0x0F) ^ mask
+}
+
+// LinearToALaw encodes a 16-bit linear sample as G.711 A-law.
+func LinearToALaw(sample int16) byte {
+	s := int32(sample) >> 3 // A-law works on 13-bit magnitudes
+	sign := byte(0x80)
+	if s < 0 {
+		s = -s - 1
+		sign = 0
+	}
+	if s > 0xFFF {
+		s = 0xFFF
+	}
+
+	var b byte
+	if s < 32 {
+		b = byte(s >> 1)
+	} else {
+		exponent := byte(1)
+		for v := s >> 5; v > 1; v >>= 1 {
+			exponent++
+		}
+		b = exponent<<4 | byte(s>>exponent)&0x0F
+	}
+	return (sign | b) ^ 0x55
+}
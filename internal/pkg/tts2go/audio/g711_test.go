@@ -0,0 +1,46 @@
+package audio
+
+import "testing"
+
+// Reference values from the ITU-T G.191 / Sun g711.c encoders.
+func TestLinearToULaw(t *testing.T) {
+	tests := []struct {
+		in   int16
+		want byte
+	}{
+		{0, 0xFF},
+		{-1, 0x7E},
+		{4, 0xFE},
+		{-4, 0x7E},
+		{1000, 0xCE},
+		{-1000, 0x4E},
+		{32767, 0x80},
+		{-32768, 0x00},
+	}
+	for _, tt := range tests {
+		if got := LinearToULaw(tt.in); got != tt.want {
+			t.Errorf("LinearToULaw(%d) = %#02x, want %#02x", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestLinearToALaw(t *testing.T) {
+	tests := []struct {
+		in   int16
+		want byte
+	}{
+		{0, 0xD5},
+		{-1, 0x55},
+		{-8, 0x55},
+		{16, 0xD4},
+		{1000, 0xFA},
+		{-1000, 0x7A},
+		{32767, 0xAA},
+		{-32768, 0x2A},
+	}
+	for _, tt := range tests {
+		if got := LinearToALaw(tt.in); got != tt.want {
+			t.Errorf("LinearToALaw(%d) = %#02x, want %#02x", tt.in, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,254 @@
+package audio
+
+import "math"
+
+const (
+	// DefaultTruePeak is the EBU R128 maximum true-peak level in dBTP.
+	DefaultTruePeak = -1.0
+
+	loudnessBlock        = 0.4 // gating block length in seconds
+	loudnessStep         = 0.1 // 75% block overlap
+	loudnessAbsoluteGate = -70.0
+	loudnessRelativeGate = -10.0
+
+	truePeakOversample = 4
+	limiterLookahead   = 0.005 // seconds
+	limiterRelease     = 0.050 // seconds to recover from full attenuation
+)
+
+// Loudness returns the integrated loudness of a in LUFS per ITU-R BS.1770-4
+// / EBU R128: K-weighted mean square over 400 ms blocks, with an absolute
+// gate at -70 LUFS and a relative gate 10 LU below the ungated level.
+// Silence returns -Inf.
+func (a *Audio) Loudness() float64 {
+	if len(a.Samples) == 0 || a.SampleRate <= 0 {
+		return math.Inf(-1)
+	}
+
+	weighted := kWeight(a.Samples, a.SampleRate)
+
+	blockLen := int(loudnessBlock * float64(a.SampleRate))
+	stepLen := int(loudnessStep * float64(a.SampleRate))
+	var blocks []float64
+	if len(weighted) < blockLen {
+		blocks = append(blocks, meanSquare(weighted))
+	} else {
+		for start := 0; start+blockLen <= len(weighted); start += stepLen {
+			blocks = append(blocks, meanSquare(weighted[start:start+blockLen]))
+		}
+	}
+
+	gated := gateBlocks(blocks, loudnessAbsoluteGate)
+	if len(gated) == 0 {
+		return math.Inf(-1)
+	}
+	relative := blockLoudness(mean(gated)) + loudnessRelativeGate
+	gated = gateBlocks(gated, relative)
+	if len(gated) == 0 {
+		return math.Inf(-1)
+	}
+	return blockLoudness(mean(gated))
+}
+
+// TruePeak returns the true-peak level of a in dBTP, estimated by 4x
+// oversampling.
+func (a *Audio) TruePeak() float64 {
+	peak := 0.0
+	for _, p := range a.truePeaks() {
+		peak = max(peak, p)
+	}
+	return amplitudeToDB(peak)
+}
+
+// NormalizeLoudness scales a to targetLUFS and then limits it so that no
+// true peak exceeds ceilingDBTP. Silent audio is returned unchanged.
+func (a *Audio) NormalizeLoudness(targetLUFS, ceilingDBTP float64) *Audio {
+	loudness := a.Loudness()
+	if math.IsInf(loudness, -1) {
+		return a
+	}
+
+	gain := float32(dbToAmplitude(targetLUFS - loudness))
+	out := make([]float32, len(a.Samples))
+	for i, s := range a.Samples {
+		out[i] = s * gain
+	}
+	scaled := &Audio{Samples: out, SampleRate: a.SampleRate}
+	return scaled.Limit(ceilingDBTP)
+}
+
+// Limit applies a lookahead true-peak limiter with a ceiling of
+// ceilingDBTP. The gain ramps down over the lookahead window ahead of each
+// peak and recovers over the release time, so the result has no hard
+// clipping.
+func (a *Audio) Limit(ceilingDBTP float64) *Audio {
+	ceiling := dbToAmplitude(ceilingDBTP)
+	peaks := a.truePeaks()
+
+	// Required gain per sample.
+	need := make([]float64, len(peaks))
+	limited := false
+	for i, p := range peaks {
+		need[i] = 1
+		if p > ceiling {
+			need[i] = ceiling / p
+			limited = true
+		}
+	}
+	if !limited {
+		return a
+	}
+
+	lookahead := max(int(limiterLookahead*float64(a.SampleRate)), 1)
+	release := 1 / (limiterRelease * float64(a.SampleRate))
+
+	// Forward minimum over the lookahead window, with a linear release so
+	// the gain recovers smoothly after a peak.
+	window := slidingMin(need, lookahead)
+	for i := 1; i < len(window); i++ {
+		window[i] = min(window[i], window[i-1]+release)
+	}
+
+	// Averaging the last lookahead values of the forward minimum keeps every
+	// gain at or below the gain required at that sample while turning steps
+	// into ramps.
+	out := make([]float32, len(a.Samples))
+	var sum float64
+	for i, s := range a.Samples {
+		sum += window[i]
+		if i >= lookahead {
+			sum -= window[i-lookahead]
+		}
+		g := sum / float64(min(i+1, lookahead))
+		out[i] = s * float32(g)
+	}
+
+	return &Audio{Samples: out, SampleRate: a.SampleRate}
+}
+
+// truePeaks returns, for each sample, the largest absolute value of the
+// signal between it and the next sample after oversampling.
+func (a *Audio) truePeaks() []float64 {
+	peaks := make([]float64, len(a.Samples))
+	for i, s := range a.Samples {
+		peaks[i] = math.Abs(float64(s))
+	}
+	if a.SampleRate <= 0 {
+		return peaks
+	}
+
+	up := a.Resample(a.SampleRate * truePeakOversample).Samples
+	for i, s := range up {
+		j := i / truePeakOversample
+		if j < len(peaks) {
+			peaks[j] = max(peaks[j], math.Abs(float64(s)))
+		}
+	}
+	return peaks
+}
+
+// kWeight applies the BS.1770 K-weighting pre-filter (high shelf followed
+// by a high-pass), with coefficients derived for any sample rate.
+func kWeight(samples []float32, sampleRate int) []float64 {
+	fs := float64(sampleRate)
+
+	// Stage 1: high shelf, +4 dB above ~1.7 kHz.
+	k := math.Tan(math.Pi * 1681.974450955533 / fs)
+	q := 0.7071752369554196
+	vh := math.Pow(10, 3.999843853973347/20)
+	vb := math.Pow(vh, 0.4996667741545416)
+	a0 := 1 + k/q + k*k
+	shelf := biquad{
+		b0: (vh + vb*k/q + k*k) / a0,
+		b1: 2 * (k*k - vh) / a0,
+		b2: (vh - vb*k/q + k*k) / a0,
+		a1: 2 * (k*k - 1) / a0,
+		a2: (1 - k/q + k*k) / a0,
+	}
+
+	// Stage 2: RLB high-pass at ~38 Hz.
+	k = math.Tan(math.Pi * 38.13547087602444 / fs)
+	q = 0.5003270373238773
+	a0 = 1 + k/q + k*k
+	highpass := biquad{
+		b0: 1,
+		b1: -2,
+		b2: 1,
+		a1: 2 * (k*k - 1) / a0,
+		a2: (1 - k/q + k*k) / a0,
+	}
+
+	out := make([]float64, len(samples))
+	for i, s := range samples {
+		out[i] = highpass.process(shelf.process(float64(s)))
+	}
+	return out
+}
+
+type biquad struct {
+	b0, b1, b2, a1, a2 float64
+	z1, z2             float64
+}
+
+// process runs one sample through the filter (transposed direct form II).
+func (f *biquad) process(x float64) float64 {
+	y := f.b0*x + f.z1
+	f.z1 = f.b1*x - f.a1*y + f.z2
+	f.z2 = f.b2*x - f.a2*y
+	return y
+}
+
+// slidingMin returns m[i] = min(x[i : i+n]).
+func slidingMin(x []float64, n int) []float64 {
+	out := make([]float64, len(x))
+	var deque []int
+	for i := len(x) - 1; i >= 0; i-- {
+		for len(deque) > 0 && x[deque[len(deque)-1]] >= x[i] {
+			deque = deque[:len(deque)-1]
+		}
+		deque = append(deque, i)
+		if deque[0] >= i+n {
+			deque = deque[1:]
+		}
+		out[i] = x[deque[0]]
+	}
+	return out
+}
+
+func gateBlocks(blocks []float64, threshold float64) []float64 {
+	var kept []float64
+	for _, z := range blocks {
+		if blockLoudness(z) > threshold {
+			kept = append(kept, z)
+		}
+	}
+	return kept
+}
+
+func blockLoudness(meanSquare float64) float64 {
+	return -0.691 + 10*math.Log10(meanSquare)
+}
+
+func meanSquare(x []float64) float64 {
+	var sum float64
+	for _, v := range x {
+		sum += v * v
+	}
+	return sum / float64(len(x))
+}
+
+func mean(x []float64) float64 {
+	var sum float64
+	for _, v := range x {
+		sum += v
+	}
+	return sum / float64(len(x))
+}
+
+func dbToAmplitude(db float64) float64 {
+	return math.Pow(10, db/20)
+}
+
+func amplitudeToDB(amp float64) float64 {
+	return 20 * math.Log10(amp)
+}
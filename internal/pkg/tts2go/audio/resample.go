@@ -0,0 +1,162 @@
+package audio
+
+import "math"
+
+const (
+	// resampleZeros is the number of sinc zero crossings on each side of
+	// the filter at unity cutoff.
+	resampleZeros = 16
+	// resampleRolloff places the cutoff slightly below Nyquist so the
+	// transition band stays out of the audible alias region.
+	resampleRolloff    = 0.945
+	resampleKaiserBeta = 8.6
+)
+
+// Resample converts a to sampleRate with a polyphase windowed-sinc filter
+// (Kaiser window). The ratio is reduced to L/M, one filter per output phase
+// is precomputed, and each output sample is a dot product of 2*half input
+// samples. When downsampling, the cutoff drops to the new Nyquist rate so
+// the result is free of aliasing.
+func (a *Audio) Resample(sampleRate int) *Audio {
+	return NewResampler(a.SampleRate, sampleRate).Resample(a, true)
+}
+
+// Resampler resamples a stream delivered in chunks. It keeps the filter
+// history and phase between calls, so the chunks join exactly as if the
+// whole stream had been resampled at once.
+type Resampler struct {
+	from, to int
+	up, down int64
+	half     int
+	bank     [][]float32
+
+	buf      []float32 // input from absolute index off on
+	off      int64
+	received int64
+	next     int64 // absolute index of the next output sample
+}
+
+// NewResampler returns a resampler from one rate to another. If either rate
+// is not positive or they are equal, it passes audio through unchanged.
+func NewResampler(from, to int) *Resampler {
+	r := &Resampler{from: from, to: to}
+	if r.passthrough() {
+		return r
+	}
+
+	g := gcd(from, to)
+	up := to / g
+	r.up, r.down = int64(up), int64(from/g)
+
+	cutoff := resampleRolloff
+	if to < from {
+		cutoff *= float64(to) / float64(from)
+	}
+	r.half = int(math.Ceil(resampleZeros / cutoff))
+	taps := 2 * r.half
+
+	r.bank = make([][]float32, up)
+	for phase := range r.bank {
+		frac := float64(phase) / float64(up)
+		h := make([]float32, taps)
+		var sum float64
+		w := make([]float64, taps)
+		for k := range w {
+			d := frac + float64(r.half-1-k)
+			w[k] = cutoff * sinc(cutoff*d) * kaiser(d/float64(r.half), resampleKaiserBeta)
+			sum += w[k]
+		}
+		for k := range h {
+			h[k] = float32(w[k] / sum)
+		}
+		r.bank[phase] = h
+	}
+	return r
+}
+
+func (r *Resampler) passthrough() bool {
+	return r.to <= 0 || r.from <= 0 || r.to == r.from
+}
+
+// Resample feeds the next chunk of the stream and returns the output that
+// is complete so far. Output near the end of a chunk waits for the next one
+// unless final is set, in which case the stream is flushed.
+func (r *Resampler) Resample(a *Audio, final bool) *Audio {
+	if r.passthrough() {
+		return a
+	}
+
+	r.buf = append(r.buf, a.Samples...)
+	r.received += int64(len(a.Samples))
+
+	taps := int64(2 * r.half)
+	total := (r.received*r.up + r.down - 1) / r.down
+	var out []float32
+	for ; r.next < total; r.next++ {
+		start := r.start(r.next)
+		if !final && start+taps > r.received {
+			break
+		}
+
+		h := r.bank[r.next*r.down%r.up]
+		var acc float32
+		for k, c := range h {
+			j := start + int64(k)
+			if j < 0 || j >= r.received {
+				continue
+			}
+			acc += r.buf[j-r.off] * c
+		}
+		out = append(out, acc)
+	}
+
+	// Drop input no later output sample needs.
+	if keep := min(max(r.start(r.next), r.off), r.received); keep > r.off {
+		r.buf = r.buf[keep-r.off:]
+		r.off = keep
+	}
+
+	return &Audio{
+		Samples:    out,
+		SampleRate: r.to,
+	}
+}
+
+// start is the absolute index of the first input sample output i reads.
+func (r *Resampler) start(i int64) int64 {
+	return i*r.down/r.up - int64(r.half) + 1
+}
+
+func sinc(x float64) float64 {
+	if x == 0 {
+		return 1
+	}
+	x *= math.Pi
+	return math.Sin(x) / x
+}
+
+func kaiser(x, beta float64) float64 {
+	if x < -1 || x > 1 {
+		return 0
+	}
+	return besselI0(beta*math.Sqrt(1-x*x)) / besselI0(beta)
+}
+
+func besselI0(x float64) float64 {
+	sum, term := 1.0, 1.0
+	for k := 1; k < 50; k++ {
+		term *= (x / (2 * float64(k))) * (x / (2 * float64(k)))
+		sum += term
+		if term < 1e-12*sum {
+			break
+		}
+	}
+	return sum
+}
+
+func gcd(a, b int) int {
+	for b != 0 {
+		a, b = b, a%b
+	}
+	return a
+}
@@ -0,0 +1,55 @@
+package audio
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestResamplerChunkedMatchesWhole(t *testing.T) {
+	rates := [][2]int{{24000, 44100}, {24000, 48000}, {24000, 8000}, {22050, 16000}, {24000, 24000}}
+	rng := rand.New(rand.NewSource(1))
+
+	in := make([]float32, 10007)
+	for i := range in {
+		in[i] = float32(math.Sin(float64(i)*0.05)) * 0.5
+	}
+
+	for _, r := range rates {
+		t.Run(fmt.Sprintf("%d-%d", r[0], r[1]), func(t *testing.T) {
+			whole := (&Audio{Samples: in, SampleRate: r[0]}).Resample(r[1])
+
+			rs := NewResampler(r[0], r[1])
+			var chunked []float32
+			for off := 0; off < len(in); {
+				n := min(1+rng.Intn(3000), len(in)-off)
+				out := rs.Resample(&Audio{Samples: in[off : off+n], SampleRate: r[0]}, off+n == len(in))
+				if out.SampleRate != whole.SampleRate {
+					t.Fatalf("chunk sample rate = %d, want %d", out.SampleRate, whole.SampleRate)
+				}
+				chunked = append(chunked, out.Samples...)
+				off += n
+			}
+
+			if len(chunked) != len(whole.Samples) {
+				t.Fatalf("chunked length = %d, want %d", len(chunked), len(whole.Samples))
+			}
+			for i := range chunked {
+				if chunked[i] != whole.Samples[i] {
+					t.Fatalf("sample %d = %v, want %v", i, chunked[i], whole.Samples[i])
+				}
+			}
+		})
+	}
+}
+
+func TestResampleLength(t *testing.T) {
+	a := &Audio{Samples: make([]float32, 24000), SampleRate: 24000}
+	if got := len(a.Resample(8000).Samples); got != 8000 {
+		t.Errorf("24 kHz -> 8 kHz: %d samples, want 8000", got)
+	}
+	if got := len(a.Resample(44100).Samples); got != 44100 {
+		t.Errorf("24 kHz -> 44.1 kHz: %d samples, want 44100", got)
+	}
+}
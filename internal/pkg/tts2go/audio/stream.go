@@ -0,0 +1,132 @@
+package audio
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+)
+
+// StreamWriter writes audio progressively as it is generated. WAV output
+// starts with a header whose sizes are set to the maximum, which players
+// treat as "read until EOF"; if the destination is seekable the sizes are
+// patched on Close. FLAC is written frame by frame and its STREAMINFO is
+// completed on Close the same way.
+type StreamWriter struct {
+	w          *bufio.Writer
+	dst        io.Writer
+	format     string
+	opts       WriteOptions
+	encoder    *sampleEncoder
+	flac       *flacWriter
+	sampleRate int
+	started    bool
+	headerSize int
+	dataBytes  int64
+}
+
+func NewStreamWriter(w io.Writer, format string, opts WriteOptions) (*StreamWriter, error) {
+	format, opts, err := resolveFormat(format, opts)
+	if err != nil {
+		return nil, err
+	}
+	return &StreamWriter{
+		w:       bufio.NewWriter(w),
+		dst:     w,
+		format:  format,
+		opts:    opts,
+		encoder: newSampleEncoder(opts),
+	}, nil
+}
+
+// Write appends a to the stream and flushes it to the destination.
+func (s *StreamWriter) Write(a *Audio) error {
+	if err := s.start(a.SampleRate); err != nil {
+		return err
+	}
+	if a.SampleRate != s.sampleRate {
+		return fmt.Errorf("sample rate changed mid-stream: %d != %d", a.SampleRate, s.sampleRate)
+	}
+	if s.flac != nil {
+		if err := s.flac.write(a.Samples); err != nil {
+			return err
+		}
+		return s.w.Flush()
+	}
+	if err := s.encoder.write(s.w, a.Samples); err != nil {
+		return err
+	}
+	s.dataBytes += int64(len(a.Samples) * NumChannels * s.encoder.format.BytesPerSample())
+	return s.w.Flush()
+}
+
+// Close flushes the stream and, for WAV or FLAC written to a seekable
+// destination, rewrites the header with the final sizes. It does not close
+// the underlying writer.
+func (s *StreamWriter) Close() error {
+	if err := s.start(SampleRate); err != nil {
+		return err
+	}
+	if s.flac != nil {
+		if err := s.flac.flush(); err != nil {
+			return err
+		}
+	}
+	if s.format == FormatWAV && s.dataBytes%2 == 1 {
+		// RIFF pad byte after an odd-sized data chunk.
+		if err := s.w.WriteByte(0); err != nil {
+			return err
+		}
+	}
+	if err := s.w.Flush(); err != nil {
+		return err
+	}
+
+	var header []byte
+	switch {
+	case s.flac != nil:
+		header = s.flac.streamInfo(true)
+	case s.format == FormatWAV && s.dataBytes <= math.MaxUint32-int64(s.headerSize):
+		header = buildWAVHeader(s.sampleRate, s.encoder.format, uint32(s.dataBytes))
+	default:
+		return nil
+	}
+
+	seeker, ok := s.dst.(io.WriteSeeker)
+	if !ok {
+		return nil
+	}
+	if _, err := seeker.Seek(0, io.SeekStart); err != nil {
+		// Pipes and terminals are not seekable; the open-ended header stands.
+		return nil
+	}
+	if _, err := seeker.Write(header); err != nil {
+		return err
+	}
+	_, err := seeker.Seek(0, io.SeekEnd)
+	return err
+}
+
+func (s *StreamWriter) start(sampleRate int) error {
+	if s.started {
+		return nil
+	}
+	s.started = true
+	s.sampleRate = sampleRate
+	if s.format == FormatFLAC {
+		fw, err := newFLACWriter(s.w, sampleRate, s.opts)
+		if err != nil {
+			return err
+		}
+		s.flac = fw
+		_, err = s.w.Write(fw.streamInfo(false))
+		return err
+	}
+	if s.format != FormatWAV {
+		return nil
+	}
+	header := buildWAVHeader(sampleRate, s.encoder.format, math.MaxUint32)
+	s.headerSize = len(header)
+	_, err := s.w.Write(header)
+	return err
+}
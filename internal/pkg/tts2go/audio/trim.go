@@ -0,0 +1,98 @@
+package audio
+
+import (
+	"math"
+	"time"
+)
+
+// DefaultTrimThreshold is the level in dBFS below which leading and
+// trailing audio counts as silence.
+const DefaultTrimThreshold = -50.0
+
+// trimMargin is kept around the first and last audible sample so that soft
+// onsets and releases are not clipped.
+const trimMargin = 5 * time.Millisecond
+
+// TrimStart removes leading samples below thresholdDB.
+func (a *Audio) TrimStart(thresholdDB float64) *Audio {
+	threshold := float32(dbToAmplitude(thresholdDB))
+	margin := durationSamples(trimMargin, a.SampleRate)
+	for i, s := range a.Samples {
+		if abs32(s) > threshold {
+			return a.slice(max(i-margin, 0), len(a.Samples))
+		}
+	}
+	return a.slice(0, 0)
+}
+
+// TrimEnd removes trailing samples below thresholdDB.
+func (a *Audio) TrimEnd(thresholdDB float64) *Audio {
+	threshold := float32(dbToAmplitude(thresholdDB))
+	margin := durationSamples(trimMargin, a.SampleRate)
+	for i := len(a.Samples) - 1; i >= 0; i-- {
+		if abs32(a.Samples[i]) > threshold {
+			return a.slice(0, min(i+1+margin, len(a.Samples)))
+		}
+	}
+	return a.slice(0, 0)
+}
+
+// FadeIn applies a raised-cosine fade over the first d of audio.
+func (a *Audio) FadeIn(d time.Duration) *Audio {
+	n := min(durationSamples(d, a.SampleRate), len(a.Samples))
+	if n == 0 {
+		return a
+	}
+	out := a.copy()
+	for i := range n {
+		out.Samples[i] *= fadeGain(i, n)
+	}
+	return out
+}
+
+// FadeOut applies a raised-cosine fade over the last d of audio.
+func (a *Audio) FadeOut(d time.Duration) *Audio {
+	n := min(durationSamples(d, a.SampleRate), len(a.Samples))
+	if n == 0 {
+		return a
+	}
+	out := a.copy()
+	last := len(out.Samples) - 1
+	for i := range n {
+		out.Samples[last-i] *= fadeGain(i, n)
+	}
+	return out
+}
+
+// Pad adds start and end of silence around the audio.
+func (a *Audio) Pad(start, end time.Duration) *Audio {
+	if start <= 0 && end <= 0 {
+		return a
+	}
+	return Concat(0, NewSilence(start, a.SampleRate), a, NewSilence(end, a.SampleRate))
+}
+
+func fadeGain(i, n int) float32 {
+	return float32(0.5 - 0.5*math.Cos(math.Pi*float64(i)/float64(n)))
+}
+
+func durationSamples(d time.Duration, sampleRate int) int {
+	return max(int(d.Seconds()*float64(sampleRate)), 0)
+}
+
+func (a *Audio) slice(start, end int) *Audio {
+	return &Audio{Samples: a.Samples[start:end], SampleRate: a.SampleRate}
+}
+
+func (a *Audio) copy() *Audio {
+	samples := make([]float32, len(a.Samples))
+	copy(samples, a.Samples)
+	return &Audio{Samples: samples, SampleRate: a.SampleRate}
+}
+
+func abs32(x float32) float32 {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
@@ -1,8 +1,9 @@
 package audio
 
 import (
-	"encoding/binary"
+	"bufio"
 	"fmt"
+	"io"
 	"math"
 	"os"
 )
@@ -32,69 +33,55 @@ func (a *Audio) SaveWAV(path string) error {
 	}
 	defer f.Close()
 
-	numSamples := len(a.Samples)
-	dataSize := numSamples * NumChannels * (BitsPerSample / 8)
-	fileSize := 36 + dataSize
-
-	if _, err := f.Write([]byte("RIFF")); err != nil {
-		return err
-	}
-	if err := binary.Write(f, binary.LittleEndian, uint32(fileSize)); err != nil {
-		return err
-	}
-	if _, err := f.Write([]byte("WAVE")); err != nil {
+	if err := a.WriteWAV(f); err != nil {
 		return err
 	}
+	return f.Close()
+}
 
-	if _, err := f.Write([]byte("fmt ")); err != nil {
-		return err
-	}
-	if err := binary.Write(f, binary.LittleEndian, uint32(16)); err != nil {
-		return err
-	}
-	if err := binary.Write(f, binary.LittleEndian, uint16(1)); err != nil {
-		return err
-	}
-	if err := binary.Write(f, binary.LittleEndian, uint16(NumChannels)); err != nil {
-		return err
-	}
-	if err := binary.Write(f, binary.LittleEndian, uint32(a.SampleRate)); err != nil {
-		return err
-	}
-	byteRate := a.SampleRate * NumChannels * (BitsPerSample / 8)
-	if err := binary.Write(f, binary.LittleEndian, uint32(byteRate)); err != nil {
-		return err
-	}
-	blockAlign := NumChannels * (BitsPerSample / 8)
-	if err := binary.Write(f, binary.LittleEndian, uint16(blockAlign)); err != nil {
-		return err
-	}
-	if err := binary.Write(f, binary.LittleEndian, uint16(BitsPerSample)); err != nil {
-		return err
+func (a *Audio) WriteWAV(w io.Writer) error {
+	return a.WriteWAVWith(w, WriteOptions{})
+}
+
+// WriteWAVWith writes a WAV file in the sample format given by opts.
+func (a *Audio) WriteWAVWith(w io.Writer, opts WriteOptions) error {
+	f := bufio.NewWriter(w)
+
+	dataSize := uint64(len(a.Samples) * NumChannels * opts.SampleFormat.BytesPerSample())
+	if dataSize > math.MaxUint32 {
+		return fmt.Errorf("audio too long for WAV: %d bytes", dataSize)
 	}
 
-	if _, err := f.Write([]byte("data")); err != nil {
+	header := buildWAVHeader(a.SampleRate, opts.SampleFormat, uint32(dataSize))
+	if _, err := f.Write(header); err != nil {
 		return err
 	}
-	if err := binary.Write(f, binary.LittleEndian, uint32(dataSize)); err != nil {
+
+	if err := newSampleEncoder(opts).write(f, a.Samples); err != nil {
 		return err
 	}
-
-	for _, sample := range a.Samples {
-		clamped := sample
-		if clamped > 1.0 {
-			clamped = 1.0
-		} else if clamped < -1.0 {
-			clamped = -1.0
-		}
-
-		intSample := int16(clamped * math.MaxInt16)
-		if err := binary.Write(f, binary.LittleEndian, intSample); err != nil {
+	if dataSize%2 == 1 {
+		if err := f.WriteByte(0); err != nil {
 			return err
 		}
 	}
 
-	return nil
+	return f.Flush()
+}
+
+// WritePCM writes the samples as headerless 16-bit little-endian PCM.
+func (a *Audio) WritePCM(w io.Writer) error {
+	return a.WritePCMWith(w, WriteOptions{})
+}
+
+// WritePCMWith writes headerless little-endian samples in the format given
+// by opts.
+func (a *Audio) WritePCMWith(w io.Writer, opts WriteOptions) error {
+	f := bufio.NewWriter(w)
+	if err := newSampleEncoder(opts).write(f, a.Samples); err != nil {
+		return err
+	}
+	return f.Flush()
 }
 
 func (a *Audio) Duration() float64 {
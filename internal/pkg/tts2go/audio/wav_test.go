@@ -0,0 +1,88 @@
+package audio
+
+import (
+	"bytes"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// wavChunks checks the RIFF size of a WAV file and returns the size of its
+// data chunk.
+func wavChunks(t *testing.T, data []byte) uint32 {
+	t.Helper()
+	if string(data[0:4]) != "RIFF" || string(data[8:12]) != "WAVE" {
+		t.Fatalf("not a RIFF/WAVE file")
+	}
+	if riff := binary.LittleEndian.Uint32(data[4:]); int(riff) != len(data)-8 {
+		t.Errorf("RIFF size = %d, want %d", riff, len(data)-8)
+	}
+
+	// Walk the chunks, honouring the pad byte after odd sizes.
+	for pos := 12; pos+8 <= len(data); {
+		id := string(data[pos : pos+4])
+		size := binary.LittleEndian.Uint32(data[pos+4:])
+		end := pos + 8 + int(size) + int(size&1)
+		if end > len(data) {
+			t.Fatalf("%s chunk overruns the file", id)
+		}
+		if id == "data" {
+			if end != len(data) {
+				t.Errorf("%d bytes after the data chunk", len(data)-end)
+			}
+			return size
+		}
+		pos = end
+	}
+	t.Fatalf("no data chunk")
+	return 0
+}
+
+func TestWAVOddDataPadded(t *testing.T) {
+	for _, format := range []SampleFormat{SampleULaw, SampleALaw, SampleS24} {
+		t.Run(format.String(), func(t *testing.T) {
+			a := &Audio{Samples: make([]float32, 5), SampleRate: 8000}
+			var buf bytes.Buffer
+			if err := a.WriteWAVWith(&buf, WriteOptions{SampleFormat: format}); err != nil {
+				t.Fatal(err)
+			}
+			if buf.Len()%2 != 0 {
+				t.Errorf("file length %d is odd", buf.Len())
+			}
+			if got, want := wavChunks(t, buf.Bytes()), uint32(5*format.BytesPerSample()); got != want {
+				t.Errorf("data size = %d, want %d", got, want)
+			}
+		})
+	}
+}
+
+func TestStreamWriterWAVOddDataPadded(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "out.wav")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	sw, err := NewStreamWriter(f, FormatWAV, WriteOptions{SampleFormat: SampleULaw})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, n := range []int{3, 4} {
+		if err := sw.Write(&Audio{Samples: make([]float32, n), SampleRate: 8000}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := sw.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := wavChunks(t, data); got != 7 {
+		t.Errorf("data size = %d, want 7", got)
+	}
+}
@@ -6,21 +6,49 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/spf13/pflag"
 	"github.com/spf13/viper"
 )
 
+const CommandServe = "serve"
+
 type Config struct {
-	ModelPath  string  `mapstructure:"model_path"`
-	VoicesPath string  `mapstructure:"voices_path"`
-	Text       string  `mapstructure:"text"`
-	Output     string  `mapstructure:"output"`
-	Voice      string  `mapstructure:"voice"`
-	Speed      float32 `mapstructure:"speed"`
-	LogLevel   string  `mapstructure:"log_level"`
-	LogFile    string  `mapstructure:"log_file"`
-	ListVoices bool    `mapstructure:"list_voices"`
+	ModelPath     string            `mapstructure:"model_path"`
+	ModelType     string            `mapstructure:"model_type"`
+	VoicesPath    string            `mapstructure:"voices_path"`
+	VocabPath     string            `mapstructure:"vocab_path"`
+	OOVPolicy     string            `mapstructure:"oov_policy"`
+	Text          string            `mapstructure:"text"`
+	Output        string            `mapstructure:"output"`
+	Voice         string            `mapstructure:"voice"`
+	Language      string            `mapstructure:"lang"`
+	Speed         float32           `mapstructure:"speed"`
+	ChunkSilence  time.Duration     `mapstructure:"chunk_silence"`
+	LogLevel      string            `mapstructure:"log_level"`
+	LogFile       string            `mapstructure:"log_file"`
+	ListVoices    bool              `mapstructure:"list_voices"`
+	Command       string            `mapstructure:"-"`
+	Listen        string            `mapstructure:"listen"`
+	VoiceAliases  map[string]string `mapstructure:"voice_aliases"`
+	Stream        bool              `mapstructure:"stream"`
+	Format        string            `mapstructure:"format"`
+	SampleFormat  string            `mapstructure:"sample_format"`
+	Dither        bool              `mapstructure:"dither"`
+	SampleRate    int               `mapstructure:"sample_rate"`
+	Loudness      float64           `mapstructure:"loudness"`
+	TruePeak      float64           `mapstructure:"true_peak"`
+	Trim          bool              `mapstructure:"trim"`
+	TrimThreshold float64           `mapstructure:"trim_threshold"`
+	FadeIn        time.Duration     `mapstructure:"fade_in"`
+	FadeOut       time.Duration     `mapstructure:"fade_out"`
+	PadStart      time.Duration     `mapstructure:"pad_start"`
+	PadEnd        time.Duration     `mapstructure:"pad_end"`
+	Timings       string            `mapstructure:"timings"`
+	TimingsFormat string            `mapstructure:"timings_format"`
+	LexiconPath   string            `mapstructure:"lexicon_path"`
+	DateOrder     string            `mapstructure:"date_order"`
 }
 
 func detectVoicesPath() string {
@@ -38,10 +66,33 @@ func detectVoicesPath() string {
 
 func LoadAndParse() (*Config, error) {
 	viper.SetDefault("model_path", "models/model.onnx")
+	viper.SetDefault("model_type", "auto")
 	viper.SetDefault("voices_path", detectVoicesPath())
+	viper.SetDefault("vocab_path", "")
+	viper.SetDefault("lexicon_path", "")
+	viper.SetDefault("date_order", "mdy")
+	viper.SetDefault("oov_policy", "drop")
 	viper.SetDefault("output", "output.wav")
 	viper.SetDefault("voice", "")
+	viper.SetDefault("lang", "")
 	viper.SetDefault("speed", 1.0)
+	viper.SetDefault("chunk_silence", "200ms")
+	viper.SetDefault("listen", ":8080")
+	viper.SetDefault("stream", false)
+	viper.SetDefault("format", "")
+	viper.SetDefault("sample_format", "s16")
+	viper.SetDefault("dither", false)
+	viper.SetDefault("sample_rate", 0)
+	viper.SetDefault("loudness", 0.0)
+	viper.SetDefault("true_peak", -1.0)
+	viper.SetDefault("trim", false)
+	viper.SetDefault("trim_threshold", -50.0)
+	viper.SetDefault("fade_in", "0s")
+	viper.SetDefault("fade_out", "0s")
+	viper.SetDefault("pad_start", "0s")
+	viper.SetDefault("pad_end", "0s")
+	viper.SetDefault("timings", "")
+	viper.SetDefault("timings_format", "")
 	viper.SetDefault("log_level", "info")
 	viper.SetDefault("log_file", "")
 
@@ -49,14 +100,37 @@ func LoadAndParse() (*Config, error) {
 	configFile := flagSet.StringP("config", "c", "", "Path to config file")
 	flagSet.StringP("text", "t", "", "Text to synthesize (use '-' to read from stdin)")
 	flagSet.StringP("file", "f", "", "Read text from file")
-	flagSet.StringP("output", "o", "", "Output WAV file")
+	flagSet.StringP("output", "o", "", "Output file (use '-' to write to stdout)")
+	flagSet.String("format", "", "Output format (wav, pcm, ulaw, alaw, flac; default: from output extension)")
+	flagSet.String("sample-format", "", "Output sample format (s16, s24, s32, f32, ulaw, alaw) (default \"s16\")")
+	flagSet.Int("sample-rate", 0, "Resample output to this rate in Hz (default: model's native rate)")
+	flagSet.Float64("loudness", 0, "Normalize to this integrated loudness in LUFS, e.g. -16 or -23 (default: off)")
+	flagSet.Float64("true-peak", -1.0, "True-peak ceiling in dBTP applied when normalizing loudness")
+	flagSet.Bool("trim", false, "Trim leading and trailing silence")
+	flagSet.Float64("trim-threshold", -50.0, "Level in dBFS below which audio counts as silence when trimming")
+	flagSet.Duration("fade-in", 0, "Fade-in length at the start of the output, e.g. 10ms")
+	flagSet.Duration("fade-out", 0, "Fade-out length at the end of the output, e.g. 10ms")
+	flagSet.Duration("pad-start", 0, "Silence added before the output")
+	flagSet.Duration("pad-end", 0, "Silence added after the output")
+	flagSet.String("timings", "", "Write word and phoneme timestamps to this file (use '-' for stdout)")
+	flagSet.String("timings-format", "", "Timings format (json, srt, vtt; default: from timings extension)")
+	flagSet.Bool("dither", false, "Apply TPDF dither when quantizing to integer samples")
+	flagSet.Bool("stream", false, "Write audio progressively as each sentence is synthesized")
 	flagSet.StringP("voice", "v", "", "Voice to use")
+	flagSet.String("lang", "", "Phonemization language, e.g. es, de, en-gb (default: voice's language, else English)")
 	flagSet.Float32P("speed", "s", 1.0, "Speech speed (0.5-2.0)")
+	flagSet.Duration("chunk-silence", 200*time.Millisecond, "Silence inserted between synthesized sentence chunks")
 	flagSet.StringP("model", "m", "", "Path to ONNX model file")
+	flagSet.String("model-type", "", "Model type (auto, kitten, kokoro, piper)")
 	flagSet.String("voices", "", "Path to voices (NPZ file or directory with .npy/.bin files)")
+	flagSet.String("vocab", "", "Path to tokenizer vocabulary (config.json, .onnx.json or tokens.txt)")
+	flagSet.String("lexicon", "", "Path to a pronunciation lexicon (TOML, JSON or plain \"word IPA\" lines)")
+	flagSet.String("date-order", "", "Day/month order of numeric dates like 03/04/2024 (mdy, dmy) (default \"mdy\")")
+	flagSet.String("oov-policy", "", "Handling of phonemes missing from the vocabulary (drop, fallback, error)")
 	flagSet.StringP("log-level", "l", "", "Log level (debug, info, warn, error)")
 	flagSet.String("log-file", "", "Log file path")
 	flagSet.Bool("list-voices", false, "List available voices and exit")
+	flagSet.String("listen", "", "Address for 'serve' to listen on (default \":8080\")")
 	helpFlag := flagSet.BoolP("help", "h", false, "Show help message")
 
 	if err := flagSet.Parse(os.Args[1:]); err != nil {
@@ -64,7 +138,7 @@ func LoadAndParse() (*Config, error) {
 	}
 
 	if *helpFlag {
-		fmt.Fprintf(os.Stderr, "Usage: tts2go [options] [text]\n\nOptions:\n")
+		fmt.Fprintf(os.Stderr, "Usage: tts2go [options] [text]\n       tts2go serve [options]\n\nOptions:\n")
 		flagSet.PrintDefaults()
 		os.Exit(0)
 	}
@@ -75,18 +149,84 @@ func LoadAndParse() (*Config, error) {
 	if err := viper.BindPFlag("output", flagSet.Lookup("output")); err != nil {
 		return nil, err
 	}
+	if err := viper.BindPFlag("format", flagSet.Lookup("format")); err != nil {
+		return nil, err
+	}
+	if err := viper.BindPFlag("sample_format", flagSet.Lookup("sample-format")); err != nil {
+		return nil, err
+	}
+	if err := viper.BindPFlag("sample_rate", flagSet.Lookup("sample-rate")); err != nil {
+		return nil, err
+	}
+	if err := viper.BindPFlag("loudness", flagSet.Lookup("loudness")); err != nil {
+		return nil, err
+	}
+	if err := viper.BindPFlag("true_peak", flagSet.Lookup("true-peak")); err != nil {
+		return nil, err
+	}
+	if err := viper.BindPFlag("trim", flagSet.Lookup("trim")); err != nil {
+		return nil, err
+	}
+	if err := viper.BindPFlag("trim_threshold", flagSet.Lookup("trim-threshold")); err != nil {
+		return nil, err
+	}
+	if err := viper.BindPFlag("fade_in", flagSet.Lookup("fade-in")); err != nil {
+		return nil, err
+	}
+	if err := viper.BindPFlag("fade_out", flagSet.Lookup("fade-out")); err != nil {
+		return nil, err
+	}
+	if err := viper.BindPFlag("pad_start", flagSet.Lookup("pad-start")); err != nil {
+		return nil, err
+	}
+	if err := viper.BindPFlag("pad_end", flagSet.Lookup("pad-end")); err != nil {
+		return nil, err
+	}
+	if err := viper.BindPFlag("timings", flagSet.Lookup("timings")); err != nil {
+		return nil, err
+	}
+	if err := viper.BindPFlag("timings_format", flagSet.Lookup("timings-format")); err != nil {
+		return nil, err
+	}
+	if err := viper.BindPFlag("dither", flagSet.Lookup("dither")); err != nil {
+		return nil, err
+	}
+	if err := viper.BindPFlag("stream", flagSet.Lookup("stream")); err != nil {
+		return nil, err
+	}
 	if err := viper.BindPFlag("voice", flagSet.Lookup("voice")); err != nil {
 		return nil, err
 	}
+	if err := viper.BindPFlag("lang", flagSet.Lookup("lang")); err != nil {
+		return nil, err
+	}
 	if err := viper.BindPFlag("speed", flagSet.Lookup("speed")); err != nil {
 		return nil, err
 	}
+	if err := viper.BindPFlag("chunk_silence", flagSet.Lookup("chunk-silence")); err != nil {
+		return nil, err
+	}
 	if err := viper.BindPFlag("model_path", flagSet.Lookup("model")); err != nil {
 		return nil, err
 	}
+	if err := viper.BindPFlag("model_type", flagSet.Lookup("model-type")); err != nil {
+		return nil, err
+	}
 	if err := viper.BindPFlag("voices_path", flagSet.Lookup("voices")); err != nil {
 		return nil, err
 	}
+	if err := viper.BindPFlag("vocab_path", flagSet.Lookup("vocab")); err != nil {
+		return nil, err
+	}
+	if err := viper.BindPFlag("lexicon_path", flagSet.Lookup("lexicon")); err != nil {
+		return nil, err
+	}
+	if err := viper.BindPFlag("date_order", flagSet.Lookup("date-order")); err != nil {
+		return nil, err
+	}
+	if err := viper.BindPFlag("oov_policy", flagSet.Lookup("oov-policy")); err != nil {
+		return nil, err
+	}
 	if err := viper.BindPFlag("log_level", flagSet.Lookup("log-level")); err != nil {
 		return nil, err
 	}
@@ -96,6 +236,9 @@ func LoadAndParse() (*Config, error) {
 	if err := viper.BindPFlag("list_voices", flagSet.Lookup("list-voices")); err != nil {
 		return nil, err
 	}
+	if err := viper.BindPFlag("listen", flagSet.Lookup("listen")); err != nil {
+		return nil, err
+	}
 
 	if *configFile != "" {
 		viper.SetConfigFile(*configFile)
@@ -128,6 +271,17 @@ func LoadAndParse() (*Config, error) {
 		cfg.VoicesPath = detectVoicesPath()
 	}
 
+	cfg.ModelType = strings.ToLower(cfg.ModelType)
+	cfg.Format = strings.ToLower(cfg.Format)
+	cfg.SampleFormat = strings.ToLower(cfg.SampleFormat)
+	cfg.TimingsFormat = strings.ToLower(cfg.TimingsFormat)
+
+	args := flagSet.Args()
+	if len(args) > 0 && args[0] == CommandServe {
+		cfg.Command = CommandServe
+		args = args[1:]
+	}
+
 	textFile, _ := flagSet.GetString("file")
 	if textFile != "" {
 		content, err := os.ReadFile(textFile)
@@ -142,13 +296,12 @@ func LoadAndParse() (*Config, error) {
 		}
 		cfg.Text = strings.TrimSpace(string(content))
 	} else if cfg.Text == "" {
-		args := flagSet.Args()
 		if len(args) > 0 {
 			cfg.Text = strings.Join(args, " ")
 		}
 	}
 
-	if cfg.Text == "" && !cfg.ListVoices {
+	if cfg.Text == "" && !cfg.ListVoices && cfg.Command != CommandServe {
 		return nil, fmt.Errorf("text is required (use -t, -f, or provide as argument)")
 	}
 
@@ -156,5 +309,35 @@ func LoadAndParse() (*Config, error) {
 		return nil, fmt.Errorf("speed must be between 0.5 and 2.0")
 	}
 
+	if cfg.SampleRate < 0 {
+		return nil, fmt.Errorf("sample rate must not be negative")
+	}
+
+	if cfg.Loudness > 0 {
+		return nil, fmt.Errorf("loudness target must be negative LUFS (or 0 to disable)")
+	}
+
+	if cfg.Loudness != 0 && cfg.Stream {
+		// Integrated loudness is measured over the whole output; per-sentence
+		// normalization would change the level from one sentence to the next.
+		return nil, fmt.Errorf("loudness normalization cannot be combined with stream (it needs the whole output)")
+	}
+
+	if cfg.TruePeak > 0 {
+		return nil, fmt.Errorf("true-peak ceiling must not be above 0 dBTP")
+	}
+
+	if cfg.FadeIn < 0 || cfg.FadeOut < 0 || cfg.PadStart < 0 || cfg.PadEnd < 0 {
+		return nil, fmt.Errorf("fade and padding durations must not be negative")
+	}
+
+	if cfg.Timings == "-" && cfg.Output == "-" {
+		return nil, fmt.Errorf("audio and timings cannot both be written to stdout")
+	}
+
+	if cfg.ChunkSilence < 0 {
+		return nil, fmt.Errorf("chunk silence must not be negative")
+	}
+
 	return &cfg, nil
 }
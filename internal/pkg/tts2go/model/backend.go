@@ -0,0 +1,125 @@
+package model
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	ort "github.com/yalue/onnxruntime_go"
+
+	"tts2go/internal/pkg/tts2go/tokenizer"
+)
+
+const (
+	ModelAuto   = "auto"
+	ModelKitten = "kitten"
+	ModelKokoro = "kokoro"
+	ModelPiper  = "piper"
+)
+
+var ModelTypes = []string{ModelAuto, ModelKitten, ModelKokoro, ModelPiper}
+
+// Backend adapts one family of ONNX TTS models to the shared pipeline: it
+// owns the tokenizer and voices, and builds the input tensors for a chunk.
+type Backend interface {
+	Name() string
+	SampleRate() int
+	Tokenizer() *tokenizer.Tokenizer
+	Voices() []string
+	// VoiceLanguage returns the language a voice was trained on, or "".
+	VoiceLanguage(voiceName string) string
+	InputNames() []string
+	OutputNames() []string
+	Inputs(c Chunk, voiceName string, speed float32) ([]ort.Value, error)
+}
+
+type signature struct {
+	inputs  []string
+	outputs []string
+}
+
+func readSignature(modelPath string) (*signature, error) {
+	inputs, outputs, err := ort.GetInputOutputInfo(modelPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to inspect ONNX model: %w", err)
+	}
+
+	sig := &signature{}
+	for _, in := range inputs {
+		sig.inputs = append(sig.inputs, in.Name)
+	}
+	for _, out := range outputs {
+		sig.outputs = append(sig.outputs, out.Name)
+	}
+	return sig, nil
+}
+
+func (s *signature) hasInput(name string) bool {
+	return slices.Contains(s.inputs, name)
+}
+
+// firstInput returns the first of the candidate names the model accepts.
+func (s *signature) firstInput(candidates ...string) (string, bool) {
+	for _, name := range candidates {
+		if s.hasInput(name) {
+			return name, true
+		}
+	}
+	return "", false
+}
+
+func (s *signature) String() string {
+	return fmt.Sprintf("inputs [%s], outputs [%s]",
+		strings.Join(s.inputs, ", "), strings.Join(s.outputs, ", "))
+}
+
+func detectModelType(modelPath string, sig *signature) (string, error) {
+	if sig.hasInput("scales") {
+		return ModelPiper, nil
+	}
+	if _, ok := sig.firstInput(styleInputNames...); ok {
+		// Kitten and Kokoro share a signature. Kokoro ships its vocabulary in
+		// a config.json next to the model, and the kokoro-onnx export names
+		// its ids input "tokens"; Kitten has neither.
+		if sig.hasInput("tokens") || hasKokoroVocab(modelPath) {
+			return ModelKokoro, nil
+		}
+		return ModelKitten, nil
+	}
+	if _, err := os.Stat(piperConfigPath(modelPath)); err == nil {
+		return ModelPiper, nil
+	}
+	return "", fmt.Errorf("unrecognized model signature (%s); set model_type to one of %s",
+		sig, strings.Join(ModelTypes[1:], ", "))
+}
+
+func hasKokoroVocab(modelPath string) bool {
+	_, err := tokenizer.LoadVocab(filepath.Join(filepath.Dir(modelPath), "config.json"))
+	return err == nil
+}
+
+func newBackend(modelType, modelPath, voicesPath string, opts Options, sig *signature) (Backend, error) {
+	switch modelType {
+	case ModelKitten, ModelKokoro:
+		return newStyleBackend(modelType, modelPath, voicesPath, opts.VocabPath, sig)
+	case ModelPiper:
+		return newPiperBackend(modelPath, opts.VocabPath, sig)
+	default:
+		return nil, fmt.Errorf("unknown model type %q (expected one of %s)",
+			modelType, strings.Join(ModelTypes, ", "))
+	}
+}
+
+func checkInputs(sig *signature, names []string) error {
+	if sig == nil {
+		return nil
+	}
+	for _, name := range names {
+		if !sig.hasInput(name) {
+			return fmt.Errorf("model has no %q input (%s)", name, sig)
+		}
+	}
+	return nil
+}
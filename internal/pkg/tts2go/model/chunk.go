@@ -0,0 +1,272 @@
+package model
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"tts2go/internal/pkg/tts2go/phonemizer"
+	"tts2go/internal/pkg/tts2go/preprocess"
+	"tts2go/internal/pkg/tts2go/tokenizer"
+)
+
+// Chunk is one model invocation's worth of framed tokens; Phonemes counts the
+// phoneme ids before framing.
+type Chunk struct {
+	Tokens   []int64
+	Phonemes int
+
+	ids   []int64
+	words []wordSpan
+}
+
+// wordSpan is the range of phoneme ids, before framing, spoken for a word.
+type wordSpan struct {
+	text       string
+	start, end int
+}
+
+type splitLevel int
+
+const (
+	splitSentences splitLevel = iota
+	splitClauses
+	splitWords
+)
+
+func (t *TTS) encodeChunks(text, language string) ([]Chunk, error) {
+	return t.encodeFitting(text, language, splitSentences)
+}
+
+// encodeFitting tokenizes text into chunks no longer than the model's token
+// budget, falling back from sentence to clause to word boundaries as needed.
+func (t *TTS) encodeFitting(text, language string, level splitLevel) ([]Chunk, error) {
+	var parts []string
+	switch level {
+	case splitSentences:
+		parts = preprocess.SplitSentences(text)
+	case splitClauses:
+		parts = preprocess.SplitClauses(text)
+	default:
+		n := len(strings.Fields(text)) / 2
+		if n < 1 {
+			n = 1
+		}
+		parts = preprocess.SplitWords(text, n)
+	}
+
+	var chunks []Chunk
+	for _, part := range parts {
+		words := t.phonemizer.PhonemizeWords(part, language)
+		phonemes := phonemizer.JoinWords(words)
+		ids, unknowns, err := t.tokenizer.EncodePhonemesChecked(phonemes)
+		if err != nil {
+			t.reportUnknowns(phonemes, unknowns)
+			return nil, fmt.Errorf("failed to tokenize %q: %w", part, err)
+		}
+		if len(ids) == 0 {
+			continue
+		}
+		// Unknowns are reported only for parts emitted as chunks; a part
+		// that is split again reports them when its pieces are encoded.
+		tokens := t.tokenizer.Frame(ids)
+		if len(tokens) <= t.opts.MaxTokens {
+			t.reportUnknowns(phonemes, unknowns)
+			chunks = append(chunks, Chunk{
+				Tokens:   tokens,
+				Phonemes: len(ids),
+				ids:      ids,
+				words:    t.wordSpans(words, len(ids)),
+			})
+			continue
+		}
+
+		switch {
+		case level < splitWords:
+			sub, err := t.encodeFitting(part, language, level+1)
+			if err != nil {
+				return nil, err
+			}
+			chunks = append(chunks, sub...)
+		case len(strings.Fields(part)) > 1:
+			sub, err := t.encodeFitting(part, language, splitWords)
+			if err != nil {
+				return nil, err
+			}
+			chunks = append(chunks, sub...)
+		default:
+			t.reportUnknowns(phonemes, unknowns)
+			spans := t.wordSpans(words, len(ids))
+			ids = ids[:t.tokenizer.Capacity(t.opts.MaxTokens)]
+			chunks = append(chunks, Chunk{
+				Tokens:   t.tokenizer.Frame(ids),
+				Phonemes: len(ids),
+				ids:      ids,
+				words:    clipSpans(spans, len(ids)),
+			})
+		}
+	}
+
+	return chunks, nil
+}
+
+// encodePhonemes encodes IPA given verbatim (e.g. by an SSML phoneme
+// element) as a single word, truncated to the token budget.
+func (t *TTS) encodePhonemes(text, phonemes string) (Chunk, error) {
+	ids, unknowns, err := t.tokenizer.EncodePhonemesChecked(phonemes)
+	t.reportUnknowns(phonemes, unknowns)
+	if err != nil {
+		return Chunk{}, fmt.Errorf("failed to tokenize %q: %w", phonemes, err)
+	}
+	ids = ids[:min(len(ids), t.tokenizer.Capacity(t.opts.MaxTokens))]
+	return Chunk{
+		Tokens:   t.tokenizer.Frame(ids),
+		Phonemes: len(ids),
+		ids:      ids,
+		words:    []wordSpan{{text: text, start: 0, end: len(ids)}},
+	}, nil
+}
+
+func (t *TTS) reportUnknowns(phonemes string, unknowns []tokenizer.Unknown) {
+	if len(unknowns) > 0 && t.opts.OnUnknownSymbols != nil {
+		t.opts.OnUnknownSymbols(phonemes, unknowns)
+	}
+}
+
+// wordSpans locates each word's phonemes, without its punctuation, in the
+// ids encoded from the joined phoneme string. If the per-word counts do not
+// add up (e.g. the OOV policy maps symbols differently in context), the
+// spans are scaled to fit.
+func (t *TTS) wordSpans(words []phonemizer.Word, total int) []wordSpan {
+	sep := t.countIDs(" ")
+	spans := make([]wordSpan, 0, len(words))
+	pos := 0
+	for i, w := range words {
+		if i > 0 {
+			pos += sep
+		}
+		pre := t.countIDs(w.PrePunct)
+		phonetic := t.countIDs(w.Phonetic)
+		post := t.countIDs(w.PostPunct)
+		spans = append(spans, wordSpan{
+			text:  w.Display(),
+			start: pos + pre,
+			end:   pos + pre + phonetic,
+		})
+		pos += pre + phonetic + post
+	}
+
+	if pos != total && pos > 0 {
+		for i := range spans {
+			spans[i].start = spans[i].start * total / pos
+			spans[i].end = spans[i].end * total / pos
+		}
+	}
+	return spans
+}
+
+// An expansion is looked for from writtenBefore words before to
+// writtenAfter words after the word index the preprocessor gave it,
+// corrected by the offset at which the last expansion was found. The
+// phonemizer may split words the preprocessor counts as one ("well-known"),
+// rarely the reverse.
+const (
+	writtenBefore = 2
+	writtenAfter  = 8
+)
+
+// restoreWritten merges the spans of words the preprocessor expanded back
+// into the text as written, so that timings show "$5" rather than "five
+// dollars". Expansions are matched in order by their spoken words near the
+// word index the preprocessor recorded; one that is not found there, such
+// as one split across chunks, keeps its spoken words.
+func restoreWritten(chunks []Chunk, expansions []preprocess.Expansion) {
+	next, drift, count := 0, 0, 0
+	for ci := range chunks {
+		spans := chunks[ci].words
+		merged := make([]wordSpan, 0, len(spans))
+		for i := 0; i < len(spans); {
+			if bareWord(spans[i].text) == "" {
+				merged = append(merged, spans[i])
+				i++
+				continue
+			}
+			for next < len(expansions) && count-expansions[next].Word-drift > writtenAfter {
+				next++
+			}
+			if next < len(expansions) {
+				e := expansions[next]
+				if count-e.Word-drift >= -writtenBefore {
+					if n, ok := matchSpoken(spans[i:], e.Spoken); ok {
+						merged = append(merged, writtenSpan(spans[i:i+n], e.Written))
+						drift = count - e.Word
+						count += n
+						i += n
+						next++
+						continue
+					}
+				}
+			}
+			merged = append(merged, spans[i])
+			count++
+			i++
+		}
+		chunks[ci].words = merged
+	}
+}
+
+// matchSpoken reports whether spans start with the words of spoken, and
+// how many spans they take.
+func matchSpoken(spans []wordSpan, spoken string) (int, bool) {
+	var words []string
+	for _, w := range strings.Fields(spoken) {
+		if w = bareWord(w); w != "" {
+			words = append(words, w)
+		}
+	}
+	if len(words) == 0 || len(words) > len(spans) {
+		return 0, false
+	}
+	for k, w := range words {
+		if !strings.EqualFold(bareWord(spans[k].text), w) {
+			return 0, false
+		}
+	}
+	return len(words), true
+}
+
+// writtenSpan is one span over spans showing written, with the punctuation
+// around the first and last span.
+func writtenSpan(spans []wordSpan, written string) wordSpan {
+	first, last := spans[0], spans[len(spans)-1]
+	bare := bareWord(first.text)
+	pre := first.text[:strings.Index(first.text, bare)]
+	bare = bareWord(last.text)
+	post := last.text[strings.LastIndex(last.text, bare)+len(bare):]
+	return wordSpan{text: pre + written + post, start: first.start, end: last.end}
+}
+
+// bareWord trims punctuation from the ends of a word.
+func bareWord(s string) string {
+	return strings.TrimFunc(s, func(r rune) bool { return !unicode.IsLetter(r) && !unicode.IsDigit(r) })
+}
+
+func (t *TTS) countIDs(phonemes string) int {
+	if phonemes == "" {
+		return 0
+	}
+	ids, _, _ := t.tokenizer.EncodePhonemesChecked(phonemes)
+	return len(ids)
+}
+
+func clipSpans(spans []wordSpan, n int) []wordSpan {
+	clipped := spans[:0]
+	for _, s := range spans {
+		if s.start >= n {
+			break
+		}
+		s.end = min(s.end, n)
+		clipped = append(clipped, s)
+	}
+	return clipped
+}
@@ -3,24 +3,62 @@ package model
 import (
 	"fmt"
 	"os"
-	"path/filepath"
 	"runtime"
+	"time"
 
 	ort "github.com/yalue/onnxruntime_go"
 
+	"tts2go/internal/pkg/tts2go/alignment"
 	"tts2go/internal/pkg/tts2go/audio"
 	"tts2go/internal/pkg/tts2go/phonemizer"
 	"tts2go/internal/pkg/tts2go/preprocess"
+	"tts2go/internal/pkg/tts2go/ssml"
 	"tts2go/internal/pkg/tts2go/tokenizer"
-	"tts2go/internal/pkg/tts2go/voice"
 )
 
 type TTS struct {
 	session      *ort.DynamicAdvancedSession
-	voices       *voice.VoiceStore
+	backend      Backend
+	outputNames  []string
 	preprocessor *preprocess.Preprocessor
 	phonemizer   *phonemizer.Phonemizer
 	tokenizer    *tokenizer.Tokenizer
+	opts         Options
+}
+
+const (
+	DefaultMaxTokens    = 510
+	DefaultChunkSilence = 200 * time.Millisecond
+)
+
+type Options struct {
+	ModelType string
+	// Language overrides the voice's default phonemization language; it may
+	// be a goruut language name ("German") or a code ("de", "en-gb").
+	Language     string
+	VocabPath    string
+	LexiconPath  string
+	DateOrder    string
+	OOVPolicy    string
+	MaxTokens    int
+	ChunkSilence time.Duration
+
+	// OnUnknownSymbols, if set, is called with the phonemes of each chunk
+	// that contained symbols missing from the model's vocabulary.
+	OnUnknownSymbols func(phonemes string, unknowns []tokenizer.Unknown)
+}
+
+func (o Options) withDefaults() Options {
+	if o.ModelType == "" {
+		o.ModelType = ModelAuto
+	}
+	if o.MaxTokens <= 0 {
+		o.MaxTokens = DefaultMaxTokens
+	}
+	if o.ChunkSilence < 0 {
+		o.ChunkSilence = 0
+	}
+	return o
 }
 
 func getOnnxRuntimeLibPath() string {
@@ -72,7 +110,9 @@ func getOnnxRuntimeLibPath() string {
 	}
 }
 
-func NewTTS(modelPath, voicesPath string) (*TTS, error) {
+func NewTTS(modelPath, voicesPath string, opts Options) (*TTS, error) {
+	opts = opts.withDefaults()
+
 	libPath := getOnnxRuntimeLibPath()
 	ort.SetSharedLibraryPath(libPath)
 
@@ -80,95 +120,307 @@ func NewTTS(modelPath, voicesPath string) (*TTS, error) {
 		return nil, fmt.Errorf("failed to initialize ONNX runtime: %w", err)
 	}
 
-	voices, err := voice.LoadVoices(voicesPath)
-	if err != nil {
-		voicesDir := filepath.Dir(voicesPath)
-		voices, err = voice.LoadVoicesFromDir(filepath.Join(voicesDir, "voices"))
+	// The signature lets backends resolve renamed inputs and find a
+	// durations output; with an explicit model type, a model that cannot be
+	// inspected is still tried.
+	sig, err := readSignature(modelPath)
+	modelType := opts.ModelType
+	if modelType == ModelAuto {
+		if err != nil {
+			return nil, err
+		}
+		modelType, err = detectModelType(modelPath, sig)
 		if err != nil {
-			return nil, fmt.Errorf("failed to load voices: %w", err)
+			return nil, err
 		}
 	}
 
-	inputNames := []string{"input_ids", "style", "speed"}
-	outputNames := []string{"waveform"}
+	oovPolicy, err := tokenizer.ParseOOVPolicy(opts.OOVPolicy)
+	if err != nil {
+		return nil, err
+	}
+	dateOrder, err := preprocess.ParseDateOrder(opts.DateOrder)
+	if err != nil {
+		return nil, err
+	}
+
+	backend, err := newBackend(modelType, modelPath, voicesPath, opts, sig)
+	if err != nil {
+		return nil, err
+	}
+	backend.Tokenizer().SetOOVPolicy(oovPolicy)
+
+	outputNames := backend.OutputNames()
+	if name, ok := sig.durationOutput(); ok {
+		outputNames = append(outputNames[:len(outputNames):len(outputNames)], name)
+	}
+
+	pre := preprocess.NewPreprocessor()
+	pre.SetDateOrder(dateOrder)
+
+	ph := phonemizer.NewPhonemizer()
+	if opts.LexiconPath != "" {
+		lexicon, err := phonemizer.LoadLexicon(opts.LexiconPath)
+		if err != nil {
+			return nil, err
+		}
+		ph.SetLexicon(lexicon)
+	}
 
 	session, err := ort.NewDynamicAdvancedSession(
 		modelPath,
-		inputNames,
+		backend.InputNames(),
 		outputNames,
 		nil,
 	)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create ONNX session: %w", err)
+		return nil, fmt.Errorf("failed to create ONNX session for %s model: %w", backend.Name(), err)
 	}
 
 	return &TTS{
 		session:      session,
-		voices:       voices,
-		preprocessor: preprocess.NewPreprocessor(),
-		phonemizer:   phonemizer.NewPhonemizer(),
-		tokenizer:    tokenizer.NewTokenizer(),
+		backend:      backend,
+		outputNames:  outputNames,
+		preprocessor: pre,
+		phonemizer:   ph,
+		tokenizer:    backend.Tokenizer(),
+		opts:         opts,
 	}, nil
 }
 
 func (t *TTS) Generate(text, voiceName string, speed float32) (*audio.Audio, error) {
-	processedText := t.preprocessor.Process(text)
-
-	phonemes := t.phonemizer.Phonemize(processedText)
+	a, _, err := t.GenerateWithTimings(text, voiceName, speed)
+	return a, err
+}
 
-	tokens := t.tokenizer.Encode(phonemes)
-	if len(tokens) == 0 {
-		return nil, fmt.Errorf("failed to tokenize text")
+// GenerateWithTimings is Generate plus the time each word and phoneme is
+// spoken. Timings come from the model's duration output where it has one
+// (e.g. timestamped Kokoro exports) and are otherwise estimated by giving
+// every token an equal share of its chunk.
+func (t *TTS) GenerateWithTimings(text, voiceName string, speed float32) (*audio.Audio, []alignment.Word, error) {
+	var parts []*audio.Audio
+	var words []alignment.Word
+	err := t.GenerateStream(text, voiceName, speed, func(part StreamPart) error {
+		parts = append(parts, part.Audio)
+		words = append(words, part.Words...)
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
 	}
+	return audio.Concat(0, parts...), words, nil
+}
 
-	voiceEmbedding, err := t.voices.Get(voiceName)
+// StreamPart is one synthesized chunk passed to a GenerateStream callback.
+type StreamPart struct {
+	Audio *audio.Audio
+	// Words holds the timings of the words in this part, relative to the
+	// start of the whole stream.
+	Words []alignment.Word
+	Index int
+	Count int
+}
+
+func (p StreamPart) First() bool { return p.Index == 0 }
+
+func (p StreamPart) Last() bool { return p.Index == p.Count-1 }
+
+// GenerateStream synthesizes text chunk by chunk and passes each chunk's
+// audio to emit as soon as it is produced. Every chunk after the first is
+// prefixed with the configured inter-chunk silence, so concatenating the
+// emitted parts yields the same audio as Generate. An error returned by
+// emit stops generation and is returned unchanged.
+//
+// Text starting with <speak> is parsed as SSML; its voice and prosody
+// elements override voiceName and scale speed, and its breaks replace the
+// inter-chunk silence.
+func (t *TTS) GenerateStream(text, voiceName string, speed float32, emit func(StreamPart) error) error {
+	var units []unit
+	var err error
+	if ssml.IsSSML(text) {
+		units, err = t.ssmlUnits(text, voiceName, speed)
+	} else {
+		units, err = t.textUnits(text, voiceName, speed)
+	}
 	if err != nil {
-		return nil, fmt.Errorf("failed to get voice embedding: %w", err)
+		return err
+	}
+	if len(units) == 0 {
+		return fmt.Errorf("failed to tokenize text")
 	}
 
-	inputIdsTensor, err := ort.NewTensor(ort.NewShape(1, int64(len(tokens))), tokens)
+	var elapsed time.Duration
+	for i, u := range units {
+		speech := &audio.Audio{SampleRate: t.backend.SampleRate()}
+		var durations []float64
+		if len(u.chunk.Tokens) > 0 {
+			speech, durations, err = t.synthesize(u.chunk, u.voice, u.speed)
+			if err != nil {
+				return fmt.Errorf("chunk %d of %d: %w", i+1, len(units), err)
+			}
+		}
+		part := speech
+		if u.gap > 0 {
+			part = audio.Concat(0, audio.NewSilence(u.gap, speech.SampleRate), speech)
+		}
+
+		offset := elapsed + sampleDuration(len(part.Samples)-len(speech.Samples), speech.SampleRate)
+		words := t.timings(u.chunk, durations, speech, offset)
+		elapsed += sampleDuration(len(part.Samples), part.SampleRate)
+
+		if err := emit(StreamPart{Audio: part, Words: words, Index: i, Count: len(units)}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// unit is one chunk to synthesize and the silence before it. A unit without
+// tokens is silence only.
+type unit struct {
+	chunk Chunk
+	voice string
+	speed float32
+	gap   time.Duration
+}
+
+func (t *TTS) textUnits(text, voiceName string, speed float32) ([]unit, error) {
+	language := t.Language(voiceName)
+
+	var processedText string
+	var expansions []preprocess.Expansion
+	if phonemizer.IsEnglish(language) {
+		processedText, expansions = t.preprocessor.ProcessExpansions(text)
+	} else {
+		processedText = t.preprocessor.Normalize(text)
+	}
+
+	chunks, err := t.encodeChunks(processedText, language)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create input_ids tensor: %w", err)
+		return nil, err
+	}
+	restoreWritten(chunks, expansions)
+
+	units := make([]unit, len(chunks))
+	for i, c := range chunks {
+		units[i] = unit{chunk: c, voice: voiceName, speed: speed}
+		if i > 0 {
+			units[i].gap = t.opts.ChunkSilence
+		}
 	}
-	defer inputIdsTensor.Destroy()
+	return units, nil
+}
 
-	styleTensor, err := ort.NewTensor(ort.NewShape(1, int64(len(voiceEmbedding))), voiceEmbedding)
+// ssmlUnits renders each SSML segment with its own voice and speed.
+// Segments follow each other without the inter-chunk silence, which only
+// separates sentences within a segment, and a break sets the silence
+// before the next segment.
+func (t *TTS) ssmlUnits(document, voiceName string, speed float32) ([]unit, error) {
+	segments, err := ssml.Parse(document)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create style tensor: %w", err)
+		return nil, err
 	}
-	defer styleTensor.Destroy()
 
-	speedData := []float32{speed}
-	speedTensor, err := ort.NewTensor(ort.NewShape(1), speedData)
+	var units []unit
+	var pending time.Duration
+	for _, seg := range segments {
+		if seg.IsBreak() {
+			pending += seg.Break
+			continue
+		}
+
+		voice := voiceName
+		if seg.Voice != "" {
+			voice = seg.Voice
+		}
+		// Same bounds as the speed option itself.
+		segSpeed := min(max(speed*seg.Rate, 0.5), 2.0)
+
+		var segUnits []unit
+		if seg.Phonemes != "" {
+			c, err := t.encodePhonemes(seg.Text, seg.Phonemes)
+			if err != nil {
+				return nil, err
+			}
+			if c.Phonemes > 0 {
+				segUnits = []unit{{chunk: c, voice: voice, speed: segSpeed}}
+			}
+		} else {
+			segUnits, err = t.textUnits(seg.Text, voice, segSpeed)
+			if err != nil {
+				return nil, err
+			}
+		}
+		if len(segUnits) == 0 {
+			continue
+		}
+		segUnits[0].gap = pending
+		pending = 0
+		units = append(units, segUnits...)
+	}
+	if pending > 0 {
+		units = append(units, unit{gap: pending})
+	}
+	return units, nil
+}
+
+func (t *TTS) synthesize(c Chunk, voiceName string, speed float32) (*audio.Audio, []float64, error) {
+	inputs, err := t.backend.Inputs(c, voiceName, speed)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create speed tensor: %w", err)
+		return nil, nil, err
 	}
-	defer speedTensor.Destroy()
+	defer destroyValues(inputs)
 
-	inputs := []ort.Value{inputIdsTensor, styleTensor, speedTensor}
-	outputs := make([]ort.Value, 1)
+	outputs := make([]ort.Value, len(t.outputNames))
 
 	if err := t.session.Run(inputs, outputs); err != nil {
-		return nil, fmt.Errorf("failed to run inference: %w", err)
+		return nil, nil, fmt.Errorf("failed to run inference: %w", err)
 	}
+	defer destroyValues(outputs)
 
 	if outputs[0] == nil {
-		return nil, fmt.Errorf("no output from model")
+		return nil, nil, fmt.Errorf("no output from model")
 	}
-	defer outputs[0].Destroy()
 
 	outputTensor, ok := outputs[0].(*ort.Tensor[float32])
 	if !ok {
-		return nil, fmt.Errorf("unexpected output tensor type")
+		return nil, nil, fmt.Errorf("unexpected output tensor type")
+	}
+
+	outputData := append([]float32(nil), outputTensor.GetData()...)
+
+	var durations []float64
+	if len(outputs) > 1 {
+		durations = tensorFloats(outputs[1])
 	}
 
-	outputData := outputTensor.GetData()
+	return &audio.Audio{Samples: outputData, SampleRate: t.backend.SampleRate()}, durations, nil
+}
 
-	return audio.NewAudio(outputData), nil
+func destroyValues(values []ort.Value) {
+	for _, v := range values {
+		if v != nil {
+			v.Destroy()
+		}
+	}
 }
 
 func (t *TTS) ListVoices() []string {
-	return t.voices.List()
+	return t.backend.Voices()
+}
+
+// Language returns the goruut language used for a voice: the configured
+// override, else the voice's own language, else English.
+func (t *TTS) Language(voiceName string) string {
+	language := t.opts.Language
+	if language == "" {
+		language = t.backend.VoiceLanguage(voiceName)
+	}
+	return phonemizer.ResolveLanguage(language)
+}
+
+func (t *TTS) ModelType() string {
+	return t.backend.Name()
 }
 
 func (t *TTS) Close() error {
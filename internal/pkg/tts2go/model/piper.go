@@ -0,0 +1,236 @@
+package model
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	ort "github.com/yalue/onnxruntime_go"
+
+	"tts2go/internal/pkg/tts2go/tokenizer"
+)
+
+const (
+	defaultPiperSampleRate  = 22050
+	defaultPiperNoiseScale  = 0.667
+	defaultPiperLengthScale = 1.0
+	defaultPiperNoiseW      = 0.8
+)
+
+type PiperConfig struct {
+	Audio struct {
+		SampleRate int `json:"sample_rate"`
+	} `json:"audio"`
+	Espeak struct {
+		Voice string `json:"voice"`
+	} `json:"espeak"`
+	Inference struct {
+		NoiseScale  float32 `json:"noise_scale"`
+		LengthScale float32 `json:"length_scale"`
+		NoiseW      float32 `json:"noise_w"`
+	} `json:"inference"`
+	PhonemeType  string             `json:"phoneme_type"`
+	PhonemeIDMap map[string][]int64 `json:"phoneme_id_map"`
+	NumSymbols   int                `json:"num_symbols"`
+	NumSpeakers  int                `json:"num_speakers"`
+	SpeakerIDMap map[string]int64   `json:"speaker_id_map"`
+
+	name string
+}
+
+func piperConfigPath(modelPath string) string {
+	return modelPath + ".json"
+}
+
+func LoadPiperConfig(path string) (*PiperConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read Piper config: %w", err)
+	}
+
+	var cfg PiperConfig
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse Piper config: %w", err)
+	}
+
+	if cfg.Audio.SampleRate <= 0 {
+		cfg.Audio.SampleRate = defaultPiperSampleRate
+	}
+	if cfg.Inference.NoiseScale == 0 {
+		cfg.Inference.NoiseScale = defaultPiperNoiseScale
+	}
+	if cfg.Inference.LengthScale == 0 {
+		cfg.Inference.LengthScale = defaultPiperLengthScale
+	}
+	if cfg.Inference.NoiseW == 0 {
+		cfg.Inference.NoiseW = defaultPiperNoiseW
+	}
+
+	cfg.name = strings.TrimSuffix(strings.TrimSuffix(filepath.Base(path), ".json"), ".onnx")
+
+	return &cfg, nil
+}
+
+func (c *PiperConfig) MultiSpeaker() bool {
+	return c.NumSpeakers > 1
+}
+
+func (c *PiperConfig) Voices() []string {
+	if !c.MultiSpeaker() || len(c.SpeakerIDMap) == 0 {
+		return []string{c.name}
+	}
+
+	names := make([]string, 0, len(c.SpeakerIDMap))
+	for name := range c.SpeakerIDMap {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (c *PiperConfig) SpeakerID(name string) (int64, error) {
+	if !c.MultiSpeaker() {
+		if name != "" && name != c.name {
+			return 0, fmt.Errorf("voice not found: %s", name)
+		}
+		return 0, nil
+	}
+
+	if name == "" {
+		return 0, nil
+	}
+	if id, ok := c.SpeakerIDMap[name]; ok {
+		return id, nil
+	}
+	return 0, fmt.Errorf("voice not found: %s", name)
+}
+
+// scales returns Piper's [noise_scale, length_scale, noise_w] input, with
+// length_scale shortened for speeds above 1.0.
+func (c *PiperConfig) scales(speed float32) []float32 {
+	lengthScale := c.Inference.LengthScale
+	if speed > 0 {
+		lengthScale /= speed
+	}
+	return []float32{c.Inference.NoiseScale, lengthScale, c.Inference.NoiseW}
+}
+
+// piperBackend drives Piper VITS models, which take interspersed phoneme ids,
+// their length, the inference scales and an optional speaker id.
+type piperBackend struct {
+	config      *PiperConfig
+	tokenizer   *tokenizer.Tokenizer
+	inputNames  []string
+	outputNames []string
+}
+
+func newPiperBackend(modelPath, vocabPath string, sig *signature) (*piperBackend, error) {
+	piperCfg, err := LoadPiperConfig(piperConfigPath(modelPath))
+	if err != nil {
+		return nil, err
+	}
+
+	vocab := piperCfg.PhonemeIDMap
+	if vocabPath == "" && len(vocab) == 0 {
+		vocabPath = filepath.Join(filepath.Dir(modelPath), "tokens.txt")
+	}
+	if vocabPath != "" {
+		vocab, err = tokenizer.LoadVocab(vocabPath)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	tok, err := tokenizer.NewPiperTokenizer(vocab)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build Piper tokenizer: %w", err)
+	}
+
+	inputNames := []string{"input", "input_lengths", "scales"}
+	if piperCfg.MultiSpeaker() || (sig != nil && sig.hasInput("sid")) {
+		inputNames = append(inputNames, "sid")
+	}
+	if err := checkInputs(sig, inputNames); err != nil {
+		return nil, err
+	}
+
+	outputNames := []string{"output"}
+	if sig != nil && len(sig.outputs) > 0 {
+		outputNames = sig.outputs[:1]
+	}
+
+	return &piperBackend{
+		config:      piperCfg,
+		tokenizer:   tok,
+		inputNames:  inputNames,
+		outputNames: outputNames,
+	}, nil
+}
+
+func (b *piperBackend) Name() string {
+	return ModelPiper
+}
+
+func (b *piperBackend) SampleRate() int {
+	return b.config.Audio.SampleRate
+}
+
+func (b *piperBackend) Tokenizer() *tokenizer.Tokenizer {
+	return b.tokenizer
+}
+
+func (b *piperBackend) Voices() []string {
+	return b.config.Voices()
+}
+
+func (b *piperBackend) VoiceLanguage(voiceName string) string {
+	return b.config.Espeak.Voice
+}
+
+func (b *piperBackend) InputNames() []string {
+	return b.inputNames
+}
+
+func (b *piperBackend) OutputNames() []string {
+	return b.outputNames
+}
+
+func (b *piperBackend) Inputs(c Chunk, voiceName string, speed float32) ([]ort.Value, error) {
+	speakerID, err := b.config.SpeakerID(voiceName)
+	if err != nil {
+		return nil, err
+	}
+
+	inputTensor, err := ort.NewTensor(ort.NewShape(1, int64(len(c.Tokens))), c.Tokens)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create input tensor: %w", err)
+	}
+
+	lengthsTensor, err := ort.NewTensor(ort.NewShape(1), []int64{int64(len(c.Tokens))})
+	if err != nil {
+		inputTensor.Destroy()
+		return nil, fmt.Errorf("failed to create input_lengths tensor: %w", err)
+	}
+
+	scalesTensor, err := ort.NewTensor(ort.NewShape(3), b.config.scales(speed))
+	if err != nil {
+		inputTensor.Destroy()
+		lengthsTensor.Destroy()
+		return nil, fmt.Errorf("failed to create scales tensor: %w", err)
+	}
+
+	inputs := []ort.Value{inputTensor, lengthsTensor, scalesTensor}
+	if len(b.inputNames) > len(inputs) {
+		sidTensor, err := ort.NewTensor(ort.NewShape(1), []int64{speakerID})
+		if err != nil {
+			destroyValues(inputs)
+			return nil, fmt.Errorf("failed to create sid tensor: %w", err)
+		}
+		inputs = append(inputs, sidTensor)
+	}
+
+	return inputs, nil
+}
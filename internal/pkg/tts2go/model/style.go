@@ -0,0 +1,157 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+
+	ort "github.com/yalue/onnxruntime_go"
+
+	"tts2go/internal/pkg/tts2go/audio"
+	"tts2go/internal/pkg/tts2go/tokenizer"
+	"tts2go/internal/pkg/tts2go/voice"
+)
+
+var (
+	idsInputNames   = []string{"input_ids", "tokens"}
+	styleInputNames = []string{"style", "ref_s"}
+	speedInputNames = []string{"speed"}
+)
+
+// styleBackend drives the StyleTTS-derived Kitten and Kokoro models, which
+// take phoneme ids, a voice style vector and a speed scalar.
+type styleBackend struct {
+	name        string
+	voices      *voice.VoiceStore
+	tokenizer   *tokenizer.Tokenizer
+	inputNames  []string
+	outputNames []string
+}
+
+func newStyleBackend(name, modelPath, voicesPath, vocabPath string, sig *signature) (*styleBackend, error) {
+	voices, err := voice.LoadVoices(voicesPath)
+	if err != nil {
+		voicesDir := filepath.Dir(voicesPath)
+		voices, err = voice.LoadVoicesFromDir(filepath.Join(voicesDir, "voices"))
+		if err != nil {
+			return nil, fmt.Errorf("failed to load voices: %w", err)
+		}
+	}
+
+	tok, err := styleTokenizer(name, modelPath, vocabPath)
+	if err != nil {
+		return nil, err
+	}
+
+	b := &styleBackend{
+		name:        name,
+		voices:      voices,
+		tokenizer:   tok,
+		inputNames:  []string{idsInputNames[0], styleInputNames[0], speedInputNames[0]},
+		outputNames: []string{"waveform"},
+	}
+
+	if sig != nil {
+		for i, candidates := range [][]string{idsInputNames, styleInputNames, speedInputNames} {
+			name, ok := sig.firstInput(candidates...)
+			if !ok {
+				return nil, fmt.Errorf("model has no %q input (%s)", candidates[0], sig)
+			}
+			b.inputNames[i] = name
+		}
+		if len(sig.outputs) > 0 {
+			b.outputNames = sig.outputs[:1]
+		}
+	}
+
+	return b, nil
+}
+
+// styleTokenizer loads the model's own vocabulary: an explicit vocabPath, or
+// the "vocab" of a config.json next to the model (Kokoro). Models without
+// one (Kitten) fall back to the built-in StyleTTS symbol table.
+func styleTokenizer(name, modelPath, vocabPath string) (*tokenizer.Tokenizer, error) {
+	framing := tokenizer.FrameLeadingPad
+	if name == ModelKokoro {
+		framing = tokenizer.FramePadded
+	}
+
+	explicit := vocabPath != ""
+	if !explicit {
+		vocabPath = filepath.Join(filepath.Dir(modelPath), "config.json")
+	}
+
+	vocab, err := tokenizer.LoadVocab(vocabPath)
+	if err != nil {
+		if explicit || !(errors.Is(err, tokenizer.ErrNoVocab) || errors.Is(err, fs.ErrNotExist)) {
+			return nil, err
+		}
+		vocab = tokenizer.BuiltinVocab()
+	}
+
+	tok, err := tokenizer.New(vocab, framing)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build %s tokenizer: %w", name, err)
+	}
+	return tok, nil
+}
+
+func (b *styleBackend) Name() string {
+	return b.name
+}
+
+func (b *styleBackend) SampleRate() int {
+	return audio.SampleRate
+}
+
+func (b *styleBackend) Tokenizer() *tokenizer.Tokenizer {
+	return b.tokenizer
+}
+
+func (b *styleBackend) Voices() []string {
+	return b.voices.List()
+}
+
+func (b *styleBackend) VoiceLanguage(voiceName string) string {
+	if b.name != ModelKokoro {
+		return ""
+	}
+	return voice.KokoroLanguage(voiceName)
+}
+
+func (b *styleBackend) InputNames() []string {
+	return b.inputNames
+}
+
+func (b *styleBackend) OutputNames() []string {
+	return b.outputNames
+}
+
+func (b *styleBackend) Inputs(c Chunk, voiceName string, speed float32) ([]ort.Value, error) {
+	voiceEmbedding, err := b.voices.Style(voiceName, c.Phonemes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get voice embedding: %w", err)
+	}
+
+	inputIdsTensor, err := ort.NewTensor(ort.NewShape(1, int64(len(c.Tokens))), c.Tokens)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create input_ids tensor: %w", err)
+	}
+
+	styleTensor, err := ort.NewTensor(ort.NewShape(1, int64(len(voiceEmbedding))), voiceEmbedding)
+	if err != nil {
+		inputIdsTensor.Destroy()
+		return nil, fmt.Errorf("failed to create style tensor: %w", err)
+	}
+
+	speedData := []float32{speed}
+	speedTensor, err := ort.NewTensor(ort.NewShape(1), speedData)
+	if err != nil {
+		inputIdsTensor.Destroy()
+		styleTensor.Destroy()
+		return nil, fmt.Errorf("failed to create speed tensor: %w", err)
+	}
+
+	return []ort.Value{inputIdsTensor, styleTensor, speedTensor}, nil
+}
@@ -0,0 +1,107 @@
+package model
+
+import (
+	"slices"
+	"time"
+
+	ort "github.com/yalue/onnxruntime_go"
+
+	"tts2go/internal/pkg/tts2go/alignment"
+	"tts2go/internal/pkg/tts2go/audio"
+)
+
+// durationOutputNames are the per-token duration outputs of timestamped
+// model exports, in order of preference.
+var durationOutputNames = []string{"durations", "duration", "pred_dur", "phoneme_durations"}
+
+func (s *signature) durationOutput() (string, bool) {
+	if s == nil {
+		return "", false
+	}
+	for _, name := range durationOutputNames {
+		if slices.Contains(s.outputs, name) {
+			return name, true
+		}
+	}
+	return "", false
+}
+
+// timings places the chunk's words on the timeline of speech, which starts
+// at offset in the full output. Durations are per framed token in model
+// frames; they are scaled so their sum matches the audio actually produced.
+func (t *TTS) timings(c Chunk, durations []float64, speech *audio.Audio, offset time.Duration) []alignment.Word {
+	n := len(c.Tokens)
+	if n == 0 || len(c.words) == 0 {
+		return nil
+	}
+	total := sampleDuration(len(speech.Samples), speech.SampleRate)
+
+	var sum float64
+	for _, d := range durations {
+		sum += max(d, 0)
+	}
+	bounds := make([]time.Duration, n+1)
+	if len(durations) == n && sum > 0 {
+		var acc float64
+		for i, d := range durations {
+			acc += max(d, 0)
+			bounds[i+1] = time.Duration(acc / sum * float64(total))
+		}
+	} else {
+		for i := range bounds {
+			bounds[i] = total * time.Duration(i) / time.Duration(n)
+		}
+	}
+
+	symbols := t.tokenizer.Symbols(c.ids)
+	words := make([]alignment.Word, 0, len(c.words))
+	for _, span := range c.words {
+		if span.end <= span.start {
+			continue
+		}
+		word := alignment.Word{Text: span.text}
+		for k := span.start; k < span.end; k++ {
+			start, end := t.tokenizer.TokenSpan(k)
+			start, end = min(start, n), min(end, n)
+			word.Phonemes = append(word.Phonemes, alignment.Phoneme{
+				Symbol: symbols[k],
+				Start:  offset + bounds[start],
+				End:    offset + bounds[end],
+			})
+		}
+		word.Start = word.Phonemes[0].Start
+		word.End = word.Phonemes[len(word.Phonemes)-1].End
+		words = append(words, word)
+	}
+	return words
+}
+
+func sampleDuration(samples, sampleRate int) time.Duration {
+	if sampleRate <= 0 {
+		return 0
+	}
+	return time.Duration(samples) * time.Second / time.Duration(sampleRate)
+}
+
+func tensorFloats(v ort.Value) []float64 {
+	switch t := v.(type) {
+	case *ort.Tensor[int64]:
+		return convertFloats(t.GetData())
+	case *ort.Tensor[int32]:
+		return convertFloats(t.GetData())
+	case *ort.Tensor[float32]:
+		return convertFloats(t.GetData())
+	case *ort.Tensor[float64]:
+		return convertFloats(t.GetData())
+	default:
+		return nil
+	}
+}
+
+func convertFloats[T int64 | int32 | float32 | float64](data []T) []float64 {
+	out := make([]float64, len(data))
+	for i, d := range data {
+		out[i] = float64(d)
+	}
+	return out
+}
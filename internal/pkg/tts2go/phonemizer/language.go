@@ -0,0 +1,95 @@
+package phonemizer
+
+import "strings"
+
+// languageCodes maps ISO 639-1 and espeak-style codes to goruut language
+// names. Anything not listed is passed to goruut unchanged.
+var languageCodes = map[string]string{
+	"en":     "English",
+	"en-us":  "EnglishAmerican",
+	"en-gb":  "EnglishBritish",
+	"es":     "Spanish",
+	"es-es":  "Spanish",
+	"es-mx":  "Spanish",
+	"es-419": "Spanish",
+	"de":     "German",
+	"fr":     "French",
+	"fr-fr":  "French",
+	"it":     "Italian",
+	"pt":     "Portuguese",
+	"pt-br":  "Portuguese",
+	"pt-pt":  "Portuguese",
+	"nl":     "Dutch",
+	"pl":     "Polish",
+	"ru":     "Russian",
+	"uk":     "Ukrainian",
+	"cs":     "Czech",
+	"sk":     "Slovak",
+	"sv":     "Swedish",
+	"da":     "Danish",
+	"nb":     "Norwegian",
+	"no":     "Norwegian",
+	"fi":     "Finnish",
+	"is":     "Icelandic",
+	"ro":     "Romanian",
+	"hu":     "Hungarian",
+	"el":     "Greek",
+	"tr":     "Turkish",
+	"ar":     "Arabic",
+	"fa":     "Farsi",
+	"he":     "Hebrew",
+	"hi":     "Hindi",
+	"bn":     "Bengali",
+	"ur":     "Urdu",
+	"ja":     "Japanese",
+	"ko":     "Korean",
+	"zh":     "ChineseMandarin",
+	"cmn":    "ChineseMandarin",
+	"yue":    "Cantonese",
+	"vi":     "VietnameseNorthern",
+	"th":     "Thai",
+	"id":     "Indonesian",
+	"ms":     "MalayLatin",
+	"sw":     "Swahili",
+	"ca":     "Catalan",
+	"eu":     "Basque",
+	"gl":     "Galician",
+	"cy":     "WelshNorth",
+	"ka":     "Georgian",
+	"kk":     "Kazakh",
+	"lv":     "Latvian",
+	"lt":     "Lithuanian",
+	"sl":     "Slovenian",
+	"sr":     "Serbian",
+	"hr":     "Croatian",
+	"bg":     "Bulgarian",
+	"mk":     "Macedonian",
+	"eo":     "Esperanto",
+	"lb":     "Luxembourgish",
+}
+
+// ResolveLanguage returns the goruut language name for a code such as
+// "de", "en-GB" or "pt_BR", or the input unchanged if it is not a code.
+func ResolveLanguage(language string) string {
+	language = strings.TrimSpace(language)
+	if language == "" {
+		return DefaultLanguage
+	}
+
+	code := strings.ToLower(strings.ReplaceAll(language, "_", "-"))
+	if name, ok := languageCodes[code]; ok {
+		return name
+	}
+	if base, _, ok := strings.Cut(code, "-"); ok {
+		if name, ok := languageCodes[base]; ok {
+			return name
+		}
+	}
+	return language
+}
+
+// IsEnglish reports whether language resolves to one of goruut's English
+// variants.
+func IsEnglish(language string) bool {
+	return strings.HasPrefix(ResolveLanguage(language), "English")
+}
@@ -0,0 +1,206 @@
+package phonemizer
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/pelletier/go-toml/v2"
+)
+
+// Lexicon pins the pronunciation of words and phrases. Entries match whole
+// words, case-sensitively, and take precedence over goruut.
+type Lexicon struct {
+	entries  map[string]string
+	maxWords int
+}
+
+func NewLexicon(entries map[string]string) *Lexicon {
+	l := &Lexicon{entries: make(map[string]string, len(entries))}
+	for word, ipa := range entries {
+		word = collapseSpaces(word)
+		l.entries[word] = strings.TrimSpace(ipa)
+		l.maxWords = max(l.maxWords, len(wordSpans(word)))
+	}
+	return l
+}
+
+// LoadLexicon reads a lexicon from a TOML or JSON table of word = IPA, or
+// from a plain text file with one "word IPA" entry per line. In plain files
+// a tab separates phrases from their IPA, and lines starting with # are
+// comments.
+func LoadLexicon(path string) (*Lexicon, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read lexicon: %w", err)
+	}
+
+	var entries map[string]string
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".toml":
+		var table map[string]any
+		if err := toml.Unmarshal(data, &table); err != nil {
+			return nil, fmt.Errorf("failed to parse lexicon %s: %w", path, err)
+		}
+		entries = make(map[string]string, len(table))
+		for word, v := range table {
+			ipa, ok := v.(string)
+			if !ok {
+				return nil, fmt.Errorf("lexicon %s: entry %q is not a string", path, word)
+			}
+			entries[word] = ipa
+		}
+	case ".json":
+		if err := json.Unmarshal(data, &entries); err != nil {
+			return nil, fmt.Errorf("failed to parse lexicon %s: %w", path, err)
+		}
+	default:
+		entries, err = parsePlainLexicon(string(data))
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse lexicon %s: %w", path, err)
+		}
+	}
+
+	for word, ipa := range entries {
+		if strings.TrimSpace(word) == "" || strings.TrimSpace(ipa) == "" {
+			return nil, fmt.Errorf("lexicon %s: empty word or pronunciation in entry %q", path, word)
+		}
+	}
+	return NewLexicon(entries), nil
+}
+
+func parsePlainLexicon(data string) (map[string]string, error) {
+	entries := make(map[string]string)
+	scanner := bufio.NewScanner(strings.NewReader(data))
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		word, ipa, ok := strings.Cut(line, "\t")
+		if !ok {
+			word, ipa, ok = strings.Cut(line, " ")
+		}
+		if !ok {
+			return nil, fmt.Errorf("line %d: want word and pronunciation", n)
+		}
+		entries[strings.TrimSpace(word)] = strings.TrimSpace(ipa)
+	}
+	return entries, scanner.Err()
+}
+
+func (l *Lexicon) Len() int {
+	return len(l.entries)
+}
+
+func (l *Lexicon) Lookup(word string) (string, bool) {
+	ipa, ok := l.entries[collapseSpaces(word)]
+	return ipa, ok
+}
+
+// apply phonemizes text, using the lexicon for the words it has and
+// phonemize for the text in between. Punctuation next to a lexicon word
+// stays with it, as goruut would have attached it.
+func (l *Lexicon) apply(text string, phonemize func(string) []Word) []Word {
+	var words []Word
+	spans := wordSpans(text)
+	last := 0
+	for i := 0; i < len(spans); {
+		n, ipa := l.match(text, spans[i:])
+		if n == 0 {
+			i++
+			continue
+		}
+		start, end := spans[i].start, spans[i+n-1].end
+		pre, post := extendPunct(text, start, end, last)
+		words = append(words, phonemize(text[last:pre])...)
+		words = append(words, Word{
+			Text:      text[start:end],
+			Phonetic:  ipa,
+			PrePunct:  text[pre:start],
+			PostPunct: text[end:post],
+		})
+		last = post
+		i += n
+	}
+	return append(words, phonemize(text[last:])...)
+}
+
+// match returns the number of words of the longest entry starting at the
+// first span.
+func (l *Lexicon) match(text string, spans []span) (int, string) {
+	for n := min(l.maxWords, len(spans)); n > 0; n-- {
+		if ipa, ok := l.Lookup(text[spans[0].start:spans[n-1].end]); ok {
+			return n, ipa
+		}
+	}
+	return 0, ""
+}
+
+type span struct {
+	start, end int
+}
+
+// wordSpans returns the byte ranges of the words in text. Apostrophes
+// inside a word (O'Reilly, don't) are part of it.
+func wordSpans(text string) []span {
+	var spans []span
+	start := -1
+	var prev rune
+	for i, r := range text {
+		inWord := isWordRune(r)
+		if !inWord && isApostrophe(r) && start >= 0 && isWordRune(prev) {
+			next, _ := utf8.DecodeRuneInString(text[i+utf8.RuneLen(r):])
+			inWord = unicode.IsLetter(next)
+		}
+		switch {
+		case inWord && start < 0:
+			start = i
+		case !inWord && start >= 0:
+			spans = append(spans, span{start, i})
+			start = -1
+		}
+		prev = r
+	}
+	if start >= 0 {
+		spans = append(spans, span{start, len(text)})
+	}
+	return spans
+}
+
+// extendPunct widens [start, end) over adjacent punctuation, up to
+// whitespace, a word or limit on the left.
+func extendPunct(text string, start, end, limit int) (int, int) {
+	for start > limit {
+		r, size := utf8.DecodeLastRuneInString(text[:start])
+		if unicode.IsSpace(r) || isWordRune(r) {
+			break
+		}
+		start -= size
+	}
+	for end < len(text) {
+		r, size := utf8.DecodeRuneInString(text[end:])
+		if unicode.IsSpace(r) || isWordRune(r) {
+			break
+		}
+		end += size
+	}
+	return start, end
+}
+
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || unicode.IsMark(r) || r == '_'
+}
+
+func isApostrophe(r rune) bool {
+	return r == '\'' || r == '’'
+}
+
+func collapseSpaces(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
@@ -7,32 +7,108 @@ import (
 	"github.com/neurlang/goruut/models/requests"
 )
 
+const DefaultLanguage = "English"
+
 type Phonemizer struct {
-	p *lib.Phonemizer
+	p        *lib.Phonemizer
+	language string
+	lexicon  *Lexicon
 }
 
 func NewPhonemizer() *Phonemizer {
 	return &Phonemizer{
-		p: lib.NewPhonemizer(nil),
+		p:        lib.NewPhonemizer(nil),
+		language: DefaultLanguage,
 	}
 }
 
+func (ph *Phonemizer) SetLanguage(language string) {
+	ph.language = ResolveLanguage(language)
+}
+
+// SetLexicon sets the pronunciations consulted before goruut; nil removes
+// them.
+func (ph *Phonemizer) SetLexicon(lexicon *Lexicon) {
+	ph.lexicon = lexicon
+}
+
+func (ph *Phonemizer) Language() string {
+	return ph.language
+}
+
 func (ph *Phonemizer) Phonemize(text string) string {
+	return ph.PhonemizeIn(text, ph.language)
+}
+
+// PhonemizeIn phonemizes text in the given language, which may be a goruut
+// language name or an ISO/espeak code; empty uses the phonemizer's default.
+func (ph *Phonemizer) PhonemizeIn(text, language string) string {
+	return JoinWords(ph.PhonemizeWords(text, language))
+}
+
+// Word is one word of phonemized text with its surrounding punctuation.
+type Word struct {
+	Text      string
+	Phonetic  string
+	PrePunct  string
+	PostPunct string
+}
+
+// Phonemes returns the word's phonemes with punctuation, as it appears in
+// the phoneme string passed to the tokenizer.
+func (w Word) Phonemes() string {
+	return w.PrePunct + w.Phonetic + w.PostPunct
+}
+
+// Display returns the word's text with punctuation.
+func (w Word) Display() string {
+	return w.PrePunct + w.Text + w.PostPunct
+}
+
+// PhonemizeWords is PhonemizeIn split into words.
+func (ph *Phonemizer) PhonemizeWords(text, language string) []Word {
+	if language == "" {
+		language = ph.language
+	}
+	if ph.lexicon != nil && ph.lexicon.Len() > 0 {
+		return ph.lexicon.apply(text, func(run string) []Word {
+			return ph.goruutWords(run, language)
+		})
+	}
+	return ph.goruutWords(text, language)
+}
+
+func (ph *Phonemizer) goruutWords(text, language string) []Word {
+	if strings.TrimSpace(text) == "" {
+		return nil
+	}
+
 	resp := ph.p.Sentence(requests.PhonemizeSentence{
-		Language: "English",
+		Language: ResolveLanguage(language),
 		Sentence: text,
 	})
 
+	words := make([]Word, 0, len(resp.Words))
+	for _, word := range resp.Words {
+		words = append(words, Word{
+			Text:      word.CleanWord,
+			Phonetic:  word.Phonetic,
+			PrePunct:  word.PrePunct,
+			PostPunct: word.PostPunct,
+		})
+	}
+	return words
+}
+
+// JoinWords rebuilds the space-separated phoneme string for words.
+func JoinWords(words []Word) string {
 	var result strings.Builder
-	for i, word := range resp.Words {
+	for i, word := range words {
 		if i > 0 {
 			result.WriteString(" ")
 		}
-		result.WriteString(word.PrePunct)
-		result.WriteString(word.Phonetic)
-		result.WriteString(word.PostPunct)
+		result.WriteString(word.Phonemes())
 	}
-
 	return result.String()
 }
 
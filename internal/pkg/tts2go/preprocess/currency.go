@@ -0,0 +1,144 @@
+package preprocess
+
+import (
+	"regexp"
+	"strings"
+)
+
+type currency struct {
+	singular, plural string
+	// minor units, for amounts with exactly two decimals; empty if the
+	// currency has none in everyday use
+	minorSingular, minorPlural string
+}
+
+var (
+	dollar = currency{"dollar", "dollars", "cent", "cents"}
+	euro   = currency{"euro", "euros", "cent", "cents"}
+	pound  = currency{"pound", "pounds", "penny", "pence"}
+	yen    = currency{"yen", "yen", "", ""}
+	rupee  = currency{"rupee", "rupees", "paisa", "paise"}
+)
+
+var currencySymbols = map[string]currency{
+	"$":   dollar,
+	"US$": dollar,
+	"€":   euro,
+	"£":   pound,
+	"¥":   yen,
+	"₹":   rupee,
+}
+
+var currencyCodes = map[string]currency{
+	"USD": dollar,
+	"EUR": euro,
+	"GBP": pound,
+	"JPY": yen,
+	"INR": rupee,
+	"CAD": {"Canadian dollar", "Canadian dollars", "cent", "cents"},
+	"AUD": {"Australian dollar", "Australian dollars", "cent", "cents"},
+	"CHF": {"Swiss franc", "Swiss francs", "centime", "centimes"},
+	"CNY": {"yuan", "yuan", "", ""},
+}
+
+// magnitudes are the suffixes of abbreviated amounts like "$3.5M".
+var magnitudes = map[string]string{
+	"k": "thousand", "K": "thousand", "thousand": "thousand",
+	"m": "million", "M": "million", "mn": "million", "million": "million",
+	"b": "billion", "B": "billion", "bn": "billion", "billion": "billion",
+	"T": "trillion", "tn": "trillion", "trillion": "trillion",
+}
+
+const (
+	currencySymbolPattern = `US\$|[$€£¥₹]`
+	currencyCodePattern   = `\b(?:USD|EUR|GBP|JPY|INR|CAD|AUD|CHF|CNY)\b`
+	amountPattern         = `(` + integerPattern + `)(?:\.(\d+))?`
+	magnitudePattern      = `(?:\s*(thousand|million|billion|trillion|mn|bn|tn|k|K|m|M|b|B|T)\b)?`
+)
+
+var (
+	// $5.99, $.50, -€3, USD 1,200, $3.5M, £2bn, $5 million
+	currencyPrefixRe = regexp.MustCompile(`([-\x{2212}]?)(` + currencySymbolPattern + `|` + currencyCodePattern + `\s?)` +
+		`(` + integerPattern + `|)(?:\.(\d+))?` + magnitudePattern)
+	// 5 EUR, 20€, 1.5bn USD
+	currencySuffixRe = regexp.MustCompile(`([-\x{2212}]?)` + amountPattern + magnitudePattern +
+		`\s?(` + currencySymbolPattern + `|` + currencyCodePattern + `)`)
+)
+
+func expandCurrencyPrefix(text string, m []int) (int, int, string, bool) {
+	cur, ok := lookupCurrency(group(text, m, 2))
+	if !ok || group(text, m, 3)+group(text, m, 4) == "" || !standalone(text, m[4], m[1]) {
+		return 0, 0, "", false
+	}
+	spoken := readAmount(cur, group(text, m, 3), group(text, m, 4), group(text, m, 5))
+	return signedMoney(text, m, spoken)
+}
+
+func expandCurrencySuffix(text string, m []int) (int, int, string, bool) {
+	cur, ok := lookupCurrency(group(text, m, 5))
+	if !ok || !standalone(text, m[4], m[5]) {
+		return 0, 0, "", false
+	}
+	spoken := readAmount(cur, group(text, m, 2), group(text, m, 3), group(text, m, 4))
+	return signedMoney(text, m, spoken)
+}
+
+func lookupCurrency(s string) (currency, bool) {
+	s = strings.TrimSpace(s)
+	if cur, ok := currencySymbols[s]; ok {
+		return cur, true
+	}
+	cur, ok := currencyCodes[s]
+	return cur, ok
+}
+
+// signedMoney prefixes "negative" when the match starts with a minus sign
+// that is not a hyphen after a word.
+func signedMoney(text string, m []int, spoken string) (int, int, string, bool) {
+	start := m[0]
+	if group(text, m, 1) != "" {
+		if start > 0 && !standalone(text, start, start) {
+			start = m[3]
+		} else {
+			spoken = "negative " + spoken
+		}
+	}
+	return start, m[1], spoken, true
+}
+
+// readAmount reads an amount of money: "five dollars and ninety nine
+// cents", "three point five million dollars", "one pound".
+func readAmount(cur currency, integer, decimals, magnitude string) string {
+	integer = groupSeparators.Replace(integer)
+	if magnitude != "" {
+		return readNumber(integer, dotted(decimals)) + " " + magnitudes[magnitude] + " " + cur.plural
+	}
+	if decimals != "" && (len(decimals) != 2 || cur.minorPlural == "") {
+		return readNumber(integer, dotted(decimals)) + " " + cur.plural
+	}
+
+	spoken := digitsToWords(integer) + " " + pluralForm(integer, cur.singular, cur.plural)
+	if decimals != "" && strings.TrimLeft(decimals, "0") != "" {
+		minor := digitsToWords(decimals) + " " + pluralForm(decimals, cur.minorSingular, cur.minorPlural)
+		if strings.TrimLeft(integer, "0") == "" {
+			return minor
+		}
+		spoken += " and " + minor
+	}
+	return spoken
+}
+
+func dotted(decimals string) string {
+	if decimals == "" {
+		return ""
+	}
+	return "." + decimals
+}
+
+// pluralForm picks singular for an amount of exactly one.
+func pluralForm(digits, singular, plural string) string {
+	if strings.TrimLeft(digits, "0") == "1" {
+		return singular
+	}
+	return plural
+}
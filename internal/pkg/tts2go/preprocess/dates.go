@@ -0,0 +1,232 @@
+package preprocess
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DateOrder is the order of day and month in numeric dates such as
+// "03/04/2024", and how dates are read aloud.
+type DateOrder string
+
+const (
+	// DateMDY reads "03/04/2024" as "March fourth, twenty twenty four".
+	DateMDY DateOrder = "mdy"
+	// DateDMY reads "03/04/2024" as "the third of April, twenty twenty four".
+	DateDMY DateOrder = "dmy"
+)
+
+func ParseDateOrder(s string) (DateOrder, error) {
+	switch o := DateOrder(strings.ToLower(s)); o {
+	case "":
+		return DateMDY, nil
+	case DateMDY, DateDMY:
+		return o, nil
+	default:
+		return "", fmt.Errorf("unknown date order %q (expected mdy or dmy)", s)
+	}
+}
+
+var monthNames = []string{
+	"January", "February", "March", "April", "May", "June", "July",
+	"August", "September", "October", "November", "December",
+}
+
+// monthPattern matches capitalized month names and their abbreviations;
+// longer alternatives come first so "Sept" wins over "Sep".
+const monthPattern = `(January|February|March|April|May|June|July|August|September|October|November|December|` +
+	`Jan|Feb|Mar|Apr|Jun|Jul|Aug|Sept|Sep|Oct|Nov|Dec)\.?`
+
+const daySuffix = `(?:st|nd|rd|th)?`
+
+var (
+	// 2023-12-25, 2023/12/25
+	isoDateRe = regexp.MustCompile(`\b(\d{4})[-/.](\d{1,2})[-/.](\d{1,2})\b`)
+	// 12/25/2023, 25.12.2023, 12/25/23
+	numericDateRe = regexp.MustCompile(`\b(\d{1,2})([/.])(\d{1,2})[/.](\d{4}|\d{2})\b`)
+	// December 25, 2023; Dec. 25th 2023; December 25
+	monthDayRe = regexp.MustCompile(`\b` + monthPattern + `\s+(\d{1,2})` + daySuffix + `(?:,?\s+(\d{4}))?\b`)
+	// 25 December 2023; 25th of Dec, 2023
+	dayMonthRe = regexp.MustCompile(`\b(\d{1,2})` + daySuffix + `\s+(?:of\s+)?` + monthPattern + `(?:,?\s+(\d{4}))?\b`)
+	// December 2023
+	monthYearRe = regexp.MustCompile(`\b` + monthPattern + `\s+(\d{4})\b`)
+	// 1990s, 2019-2020, 2020-21, 1066 AD, and 4-digit numbers after a word
+	// that introduces a year ("in 2024")
+	yearRe = regexp.MustCompile(`\b(\d{4})(?:(s)\b|\s*[-\x{2013}]\s*(\d{4}|\d{2})\b|\s+(AD|BC|BCE|CE)\b|\b)`)
+
+	precededByThe = regexp.MustCompile(`(?i)\bthe\s+$`)
+	yearContextRe = regexp.MustCompile(`(?i)\b(?:in|since|by|from|until|till|through|before|after|during|of|year|circa|around|early|late|mid|spring|summer|autumn|fall|winter)\s+$`)
+)
+
+func (p *Preprocessor) expandISODate(text string, m []int) (int, int, string, bool) {
+	year, month, day := group(text, m, 1), group(text, m, 2), group(text, m, 3)
+	return m[0], m[1], p.readDate(text[m[0]:m[1]], year, month, day), true
+}
+
+func (p *Preprocessor) expandNumericDate(text string, m []int) (int, int, string, bool) {
+	first, sep, second, year := group(text, m, 1), group(text, m, 2), group(text, m, 3), group(text, m, 4)
+	if sep == "." && len(year) != 4 {
+		// "1.2.23" is more likely a version than a date.
+		return 0, 0, "", false
+	}
+
+	month, day := first, second
+	if p.dateOrder == DateDMY {
+		month, day = second, first
+	}
+	// A value above 12 can only be the day, whatever the locale.
+	if n, _ := strconv.Atoi(month); n > 12 {
+		month, day = day, month
+	}
+	return m[0], m[1], p.readDate(text[m[0]:m[1]], year, month, day), true
+}
+
+func expandMonthDay(text string, m []int) (int, int, string, bool) {
+	month := monthNumber(group(text, m, 1))
+	spoken, ok := readDayMonth(group(text, m, 3), month, group(text, m, 2), DateMDY)
+	if !ok {
+		return 0, 0, "", false
+	}
+	if year := group(text, m, 3); year != "" {
+		spoken += ", " + yearToWords(year)
+	}
+	return m[0], m[1], spoken, true
+}
+
+func expandDayMonth(text string, m []int) (int, int, string, bool) {
+	month := monthNumber(group(text, m, 2))
+	spoken, ok := readDayMonth(group(text, m, 3), month, group(text, m, 1), DateDMY)
+	if !ok {
+		return 0, 0, "", false
+	}
+	if precededByThe.MatchString(text[max(m[0]-8, 0):m[0]]) {
+		// "the 4th of July" already has its article.
+		spoken = strings.TrimPrefix(spoken, "the ")
+	}
+	if year := group(text, m, 3); year != "" {
+		spoken += ", " + yearToWords(year)
+	}
+	return m[0], m[1], spoken, true
+}
+
+func expandMonthYear(text string, m []int) (int, int, string, bool) {
+	month := monthNumber(group(text, m, 1))
+	return m[0], m[1], monthNames[month-1] + " " + yearToWords(group(text, m, 2)), true
+}
+
+func expandYear(text string, m []int) (int, int, string, bool) {
+	year := group(text, m, 1)
+	if !isYear(year) {
+		return 0, 0, "", false
+	}
+
+	switch {
+	case group(text, m, 2) != "":
+		return m[0], m[1], pluralWord(yearToWords(year)), true
+	case group(text, m, 3) != "":
+		// Read as years whichever way the range runs ("2000-1999"). A
+		// two-digit end must follow the start ("2020-21"), or it is more
+		// likely a month ("2020-05").
+		end := group(text, m, 3)
+		if len(end) == 2 && end <= year[2:] || len(end) == 4 && !isYear(end) {
+			return 0, 0, "", false
+		}
+		return m[0], m[1], yearToWords(year) + " to " + yearToWords(end), true
+	case group(text, m, 4) != "":
+		era := strings.Join(strings.Split(group(text, m, 4), ""), " ")
+		return m[0], m[1], yearToWords(year) + " " + era, true
+	}
+
+	if !yearContextRe.MatchString(text[max(m[0]-16, 0):m[0]]) || !standalone(text, m[0], m[1]) {
+		return 0, 0, "", false
+	}
+	return m[0], m[1], yearToWords(year), true
+}
+
+// readDate reads a full numeric date in the preprocessor's date order. A
+// date that does not exist ("13/13/2023", "2023-02-30") is returned as
+// written: reading its parts as numbers would only garble it.
+func (p *Preprocessor) readDate(written, year, month, day string) string {
+	m, _ := strconv.Atoi(month)
+	spoken, ok := readDayMonth(year, m, day, p.dateOrder)
+	if !ok {
+		return written
+	}
+	return spoken + ", " + yearToWords(year)
+}
+
+// readDayMonth reads "December twenty fifth" (mdy) or "the twenty fifth
+// of December" (dmy), rejecting days that the month does not have. Without
+// a year, February has 29 days.
+func readDayMonth(year string, month int, day string, order DateOrder) (string, bool) {
+	d, _ := strconv.Atoi(day)
+	if month < 1 || month > 12 || d < 1 || d > daysIn(year, month) {
+		return "", false
+	}
+	dayWords := ordinalToWords(strconv.Itoa(d))
+	if order == DateDMY {
+		return "the " + dayWords + " of " + monthNames[month-1], true
+	}
+	return monthNames[month-1] + " " + dayWords, true
+}
+
+func daysIn(year string, month int) int {
+	y, err := strconv.Atoi(year)
+	if err != nil {
+		y = 2000 // a leap year
+	}
+	return time.Date(y, time.Month(month)+1, 0, 0, 0, 0, 0, time.UTC).Day()
+}
+
+func monthNumber(name string) int {
+	name = strings.TrimSuffix(name, ".")
+	for i, full := range monthNames {
+		if strings.HasPrefix(full, name) {
+			return i + 1
+		}
+	}
+	return 0
+}
+
+func isYear(digits string) bool {
+	n, _ := strconv.Atoi(digits)
+	return len(digits) == 4 && n >= 1000 && n <= 2099
+}
+
+// yearToWords reads a year the way it is spoken: "1066" -> "ten sixty
+// six", "1905" -> "nineteen oh five", "1900" -> "nineteen hundred", "2005"
+// -> "two thousand five", "2024" -> "twenty twenty four". Two-digit years
+// are read as numbers ("'23" -> "twenty three", "05" -> "oh five").
+func yearToWords(digits string) string {
+	n, _ := strconv.Atoi(digits)
+	if len(digits) == 2 {
+		if n < 10 {
+			return "oh " + digitsToWords(digits)
+		}
+		return digitsToWords(digits)
+	}
+
+	hi, lo := n/100, n%100
+	switch {
+	case n >= 2000 && n < 2010, lo == 0 && hi%10 == 0:
+		return digitsToWords(digits)
+	case lo == 0:
+		return chunkToWords(hi) + " hundred"
+	case lo < 10:
+		return chunkToWords(hi) + " oh " + chunkToWords(lo)
+	default:
+		return chunkToWords(hi) + " " + chunkToWords(lo)
+	}
+}
+
+// pluralWord pluralizes the last word: "nineteen ninety" -> "nineteen
+// nineties".
+func pluralWord(words string) string {
+	if strings.HasSuffix(words, "y") {
+		return strings.TrimSuffix(words, "y") + "ies"
+	}
+	return words + "s"
+}
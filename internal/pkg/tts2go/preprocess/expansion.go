@@ -0,0 +1,65 @@
+package preprocess
+
+import (
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// Expansion is text the preprocessor rewrote to be read aloud, such as "$5"
+// read as "five dollars" or "I'm" as "I am".
+type Expansion struct {
+	Written string
+	Spoken  string
+	// Word is the index of the first spoken word among the words of the
+	// processed text, counting only words with a letter or digit.
+	Word int
+}
+
+// located is an expansion at a byte offset in the text being rewritten.
+type located struct {
+	Expansion
+	pos int
+}
+
+// shift moves the offset of each expansion in text over the replacements
+// later made in it.
+func shift(expansions []located, replacements []replacement) {
+	for i := range expansions {
+		delta := 0
+		for _, r := range replacements {
+			if r.end > expansions[i].pos {
+				break
+			}
+			delta += len(r.spoken) - (r.end - r.start)
+		}
+		expansions[i].pos += delta
+	}
+}
+
+// wordIndexes orders expansions in text and sets the word index of each.
+func wordIndexes(text string, expansions []located) []Expansion {
+	slices.SortFunc(expansions, func(a, b located) int { return a.pos - b.pos })
+
+	out := make([]Expansion, 0, len(expansions))
+	words, last := 0, 0
+	for _, e := range expansions {
+		words += countWords(text[last:e.pos])
+		last = e.pos
+		if e.Written != e.Spoken {
+			e.Word = words
+			out = append(out, e.Expansion)
+		}
+	}
+	return out
+}
+
+func countWords(text string) int {
+	n := 0
+	for _, field := range strings.Fields(text) {
+		if strings.IndexFunc(field, func(r rune) bool { return unicode.IsLetter(r) || unicode.IsDigit(r) }) >= 0 {
+			n++
+		}
+	}
+	return n
+}
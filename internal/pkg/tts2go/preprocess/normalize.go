@@ -0,0 +1,369 @@
+package preprocess
+
+import (
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// rule verbalizes one kind of token, such as an amount of money or a time.
+// expand gets the submatch indices of a match of re in text and returns the
+// range to replace, which may be narrower than the match, and its spoken
+// form; ok is false to leave the match alone.
+type rule struct {
+	re     *regexp.Regexp
+	expand func(text string, m []int) (start, end int, spoken string, ok bool)
+}
+
+// rules are applied in priority order: each rule only rewrites text that no
+// earlier rule has claimed, so specific patterns ("$5.99", "3:30 pm",
+// "21st", "12/25/2023") win over the generic number rule that would
+// otherwise read their digits.
+type rules []rule
+
+func (p *Preprocessor) englishRules() rules {
+	return rules{
+		{re: isoDateRe, expand: p.expandISODate},
+		{re: numericDateRe, expand: p.expandNumericDate},
+		{re: monthDayRe, expand: expandMonthDay},
+		{re: dayMonthRe, expand: expandDayMonth},
+		{re: monthYearRe, expand: expandMonthYear},
+		{re: currencyPrefixRe, expand: expandCurrencyPrefix},
+		{re: currencySuffixRe, expand: expandCurrencySuffix},
+		{re: timeRe, expand: expandTime},
+		{re: fractionRe, expand: expandFraction},
+		{re: ordinalRe, expand: expandOrdinal},
+		{re: unitRe, expand: expandUnit},
+		{re: yearRe, expand: expandYear},
+		{re: numberRe, expand: expandNumber},
+	}
+}
+
+type replacement struct {
+	start, end int
+	spoken     string
+}
+
+// match returns the replacements the rules make in text, in text order.
+func (rs rules) match(text string) []replacement {
+	claimed := make([]bool, len(text))
+	var replacements []replacement
+	for _, r := range rs {
+		// Match only within text no earlier rule has claimed, so that a
+		// claimed token splits a longer match instead of suppressing it.
+		for _, seg := range unclaimed(claimed) {
+			for _, m := range r.re.FindAllStringSubmatchIndex(text[seg.start:seg.end], -1) {
+				for i := range m {
+					if m[i] >= 0 {
+						m[i] += seg.start
+					}
+				}
+				start, end, spoken, ok := r.expand(text, m)
+				if !ok {
+					continue
+				}
+				for i := start; i < end; i++ {
+					claimed[i] = true
+				}
+				replacements = append(replacements, replacement{start, end, spoken})
+			}
+		}
+	}
+	slices.SortFunc(replacements, func(a, b replacement) int { return a.start - b.start })
+	return replacements
+}
+
+// replace makes the sorted replacements in text and returns each as an
+// expansion at its offset in the result.
+func replace(text string, replacements []replacement) (string, []located) {
+	if len(replacements) == 0 {
+		return text, nil
+	}
+
+	var result strings.Builder
+	expansions := make([]located, 0, len(replacements))
+	last := 0
+	for _, r := range replacements {
+		result.WriteString(text[last:r.start])
+		expansions = append(expansions, located{
+			Expansion: Expansion{Written: text[r.start:r.end], Spoken: r.spoken},
+			pos:       result.Len(),
+		})
+		result.WriteString(r.spoken)
+		last = r.end
+	}
+	result.WriteString(text[last:])
+	return result.String(), expansions
+}
+
+// unclaimed returns the runs of unclaimed bytes.
+func unclaimed(claimed []bool) []span {
+	var spans []span
+	start := -1
+	for i, c := range claimed {
+		switch {
+		case !c && start < 0:
+			start = i
+		case c && start >= 0:
+			spans = append(spans, span{start, i})
+			start = -1
+		}
+	}
+	if start >= 0 {
+		spans = append(spans, span{start, len(claimed)})
+	}
+	return spans
+}
+
+type span struct {
+	start, end int
+}
+
+// group returns submatch i of m, or "" if it did not participate.
+func group(text string, m []int, i int) string {
+	if m[2*i] < 0 {
+		return ""
+	}
+	return text[m[2*i]:m[2*i+1]]
+}
+
+// standalone reports whether text[start:end] is not run together with
+// letters or digits on either side.
+func standalone(text string, start, end int) bool {
+	before, _ := utf8.DecodeLastRuneInString(text[:start])
+	after, _ := utf8.DecodeRuneInString(text[end:])
+	return (start == 0 || !isWordChar(before)) && (end == len(text) || !isWordChar(after))
+}
+
+func isWordChar(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_'
+}
+
+var timeRe = regexp.MustCompile(`\b(\d{1,2}):(\d{2})(?:\s*(am|pm|AM|PM))?\b`)
+
+func expandTime(text string, m []int) (int, int, string, bool) {
+	hour, _ := strconv.Atoi(group(text, m, 1))
+	minute, _ := strconv.Atoi(group(text, m, 2))
+	suffix := strings.ToLower(group(text, m, 3))
+	if hour > 24 || minute > 59 {
+		return 0, 0, "", false
+	}
+
+	spoken := numberToWords(int64(hour))
+	switch {
+	case minute == 0 && suffix == "":
+		spoken += " o'clock"
+	case minute == 0:
+	case minute < 10:
+		spoken += " oh " + numberToWords(int64(minute))
+	default:
+		spoken += " " + numberToWords(int64(minute))
+	}
+	if suffix != "" {
+		spoken += " " + suffix
+	}
+	return m[0], m[1], spoken, true
+}
+
+var ordinalRe = regexp.MustCompile(`\b(\d+)(st|nd|rd|th)\b`)
+
+func expandOrdinal(text string, m []int) (int, int, string, bool) {
+	return m[0], m[1], ordinalToWords(group(text, m, 1)), true
+}
+
+var ordinalExceptions = map[string]string{
+	"one": "first", "two": "second", "three": "third", "five": "fifth",
+	"eight": "eighth", "nine": "ninth", "twelve": "twelfth",
+}
+
+// ordinalToWords reads digits as an ordinal by changing the last word of
+// the cardinal ("one hundred twenty one" -> "one hundred twenty first").
+func ordinalToWords(digits string) string {
+	words := strings.Fields(digitsToWords(digits))
+	last := words[len(words)-1]
+	switch {
+	case ordinalExceptions[last] != "":
+		last = ordinalExceptions[last]
+	case strings.HasSuffix(last, "y"):
+		last = strings.TrimSuffix(last, "y") + "ieth"
+	default:
+		last += "th"
+	}
+	words[len(words)-1] = last
+	return strings.Join(words, " ")
+}
+
+// integerPattern matches digits with optional thousands separators: commas
+// ("1,000,000"), no-break or thin spaces ("10\u00a0000"), or plain spaces
+// when there are at least two groups ("1 000 000"). A single plain space
+// separates two numbers more often than it groups one ("7 200 points").
+const integerPattern = `\d{1,3}(?:,\d{3})+|\d{1,3}(?:[\x{00A0}\x{2009}\x{202F}]\d{3})+\b|\d{1,3}(?: \d{3}){2,}\b|\d+`
+
+var groupSeparators = strings.NewReplacer(",", "", " ", "", "\u00a0", "", "\u2009", "", "\u202f", "")
+
+// numberRe matches an optionally signed number with optional thousands
+// separators, decimals and percent sign, and an optional second number
+// making it a range. The integer part may be left out (".5").
+var numberRe = regexp.MustCompile(
+	`([-+\x{2212}]?)(` + integerPattern + `|)((?:\.\d+)*)(\s?%)?` +
+		`(?:(-|\s*\x{2013}\s*)(` + integerPattern + `)((?:\.\d+)*)(\s?%)?)?`)
+
+// expandNumber reads numbers as words: "3.14" -> "three point one four",
+// "-1,000" -> "negative one thousand", "5-10%" -> "five to ten percent".
+// Numbers run together with letters ("A4", "5kg") are left alone.
+func expandNumber(text string, m []int) (int, int, string, bool) {
+	if group(text, m, 2) == "" && group(text, m, 3) == "" {
+		return 0, 0, "", false
+	}
+	start, end := m[0], m[1]
+	sign := group(text, m, 1)
+	if sign != "" && start > 0 {
+		before, _ := utf8.DecodeLastRuneInString(text[:start])
+		if !unicode.IsSpace(before) && !strings.ContainsRune("([{/", before) {
+			// A hyphen after a word is not a minus sign.
+			start = m[3]
+			sign = ""
+		}
+	}
+	if !standalone(text, start+len(sign), end) {
+		return 0, 0, "", false
+	}
+
+	spoken := signWord(sign) + readNumber(group(text, m, 2), group(text, m, 3))
+	percent := group(text, m, 4) != ""
+	if m[10] >= 0 {
+		if phoneLike(text, m) {
+			return 0, 0, "", false
+		}
+		second := readNumber(group(text, m, 6), group(text, m, 7))
+		if !isRange(sign, text, m) {
+			// "a 10-5 vote": two numbers, each with its own percent sign.
+			if percent {
+				spoken += " percent"
+			}
+			spoken += group(text, m, 5) + second
+			if group(text, m, 8) != "" {
+				spoken += " percent"
+			}
+			return start, end, spoken, true
+		}
+		// "5%-10%" and "5-10%" both read the percent once, at the end.
+		spoken += " to " + second
+		percent = percent || group(text, m, 8) != ""
+	}
+	if percent {
+		spoken += " percent"
+	}
+	return start, end, spoken, true
+}
+
+// isRange reports whether the two numbers of a numberRe match read as a
+// range: the second must be larger, and of a similar number of digits
+// ("5-10", "95-105", but not "10-5" or "1-2000").
+func isRange(sign, text string, m []int) bool {
+	first := groupSeparators.Replace(group(text, m, 2))
+	second := groupSeparators.Replace(group(text, m, 6))
+	if first == "" || len(second)-len(first) > 1 {
+		return false
+	}
+	from := numberValue(first, group(text, m, 3))
+	if signWord(sign) == "negative " {
+		from = -from
+	}
+	return numberValue(second, group(text, m, 7)) > from
+}
+
+// numberValue returns the value of an integer part and its first decimal
+// group, ignoring any further groups of a version number.
+func numberValue(integer, decimals string) float64 {
+	if parts := strings.Split(decimals, "."); len(parts) > 1 {
+		integer += "." + parts[1]
+	}
+	v, _ := strconv.ParseFloat(integer, 64)
+	return v
+}
+
+// phoneLike reports whether a hyphenated numberRe match is a phone number
+// such as "555-1234", which is left for the reader rather than read as a
+// range.
+func phoneLike(text string, m []int) bool {
+	return group(text, m, 5) == "-" && len(group(text, m, 2)) == 3 && len(group(text, m, 6)) == 4 &&
+		group(text, m, 3) == "" && group(text, m, 4) == "" && group(text, m, 7) == "" && group(text, m, 8) == ""
+}
+
+// readNumber reads an integer part and its ".ddd" decimal groups. Leading
+// zeros ("007") are read digit by digit, and each further dot of a version
+// number is read as another "point". An empty integer part is not read
+// (".5" -> "point five").
+func readNumber(integer, decimals string) string {
+	integer = groupSeparators.Replace(integer)
+	var words []string
+	switch {
+	case integer == "":
+	case len(integer) > 1 && integer[0] == '0':
+		words = append(words, digitsByOne(integer))
+	default:
+		words = append(words, digitsToWords(integer))
+	}
+	for _, d := range strings.Split(decimals, ".")[1:] {
+		words = append(words, "point", digitsByOne(d))
+	}
+	return strings.Join(words, " ")
+}
+
+// fractionRe matches a simple fraction, optionally after a whole number:
+// "3/4", "1 1/2".
+var fractionRe = regexp.MustCompile(`\b(?:(\d{1,2})\s+)?(\d{1,2})/(\d{1,2})\b`)
+
+// expandFraction reads proper fractions with small denominators: "3/4" ->
+// "three quarters", "1 1/2" -> "one and a half". Anything else, such as
+// "24/7" or "1/2/3", is left to the number rule.
+func expandFraction(text string, m []int) (int, int, string, bool) {
+	num, _ := strconv.Atoi(group(text, m, 2))
+	den, _ := strconv.Atoi(group(text, m, 3))
+	if num < 1 || den < 2 || den > 16 || num >= den ||
+		strings.HasSuffix(text[:m[0]], "/") || strings.HasPrefix(text[m[1]:], "/") {
+		return 0, 0, "", false
+	}
+
+	var name string
+	switch den {
+	case 2:
+		name = "half"
+	case 4:
+		name = "quarter"
+	default:
+		name = ordinalToWords(strconv.Itoa(den))
+	}
+	if num > 1 {
+		if name == "half" {
+			name = "halves"
+		} else {
+			name += "s"
+		}
+	}
+
+	whole := group(text, m, 1)
+	switch {
+	case whole == "":
+		return m[0], m[1], numberToWords(int64(num)) + " " + name, true
+	case num == 1:
+		return m[0], m[1], digitsToWords(whole) + " and a " + name, true
+	default:
+		return m[0], m[1], digitsToWords(whole) + " and " + numberToWords(int64(num)) + " " + name, true
+	}
+}
+
+func signWord(sign string) string {
+	switch sign {
+	case "-", "−":
+		return "negative "
+	case "+":
+		return "plus "
+	default:
+		return ""
+	}
+}
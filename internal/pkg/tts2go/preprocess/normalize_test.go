@@ -0,0 +1,95 @@
+package preprocess
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestProcess(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		// Each token type, and the rule that must win it over the number rule.
+		{"$5.99", "five dollars and ninety nine cents"},
+		{"3:30 pm", "three thirty pm"},
+		{"21st", "twenty first"},
+		{"12/25/2023", "December twenty fifth, twenty twenty three"},
+		{"5-10%", "five to ten percent"},
+		{"-5", "negative five"},
+		{"1,000,000", "one million"},
+		{"NASA's", "NASA's"},
+		{"It's been", "It has been"},
+
+		// Precedence between rules.
+		{"at 3:30 on 12/25/2023", "at three thirty on December twenty fifth, twenty twenty three"},
+		{"the 21st of May", "the twenty first of May"},
+		{"5-10 km", "five to ten kilometers"},
+		{"in 2024 it cost $3", "in twenty twenty four it cost three dollars"},
+		{"2024 items", "two thousand twenty four items"},
+		{"2019-2020", "twenty nineteen to twenty twenty"},
+		{"2000-1999", "two thousand to nineteen ninety nine"},
+		{"2020-21", "twenty twenty to twenty one"},
+		{"I'm 5 ft tall", "I am five feet tall"},
+		{"2 m tall", "two m tall"},
+		{"a 2m wall", "a two meters wall"},
+		{"5 h", "five h"},
+		{"5h later", "five hours later"},
+		{"20 km/h", "twenty kilometers per hour"},
+
+		// Spaces and hyphens between numbers that do not group or range them.
+		{"1 000 000 people", "one million people"},
+		{"10\u00a0000 people", "ten thousand people"},
+		{"Give me 3 100 dollar bills", "Give me three one hundred dollar bills"},
+		{"I scored 7 200 points", "I scored seven two hundred points"},
+		{"Tel: +1 555 1234", "Tel: plus one five hundred fifty five one thousand two hundred thirty four"},
+		{"Call 555-1234", "Call 555-1234"},
+		{"a 10-5 vote", "a ten-five vote"},
+		{"1-2000", "one-two thousand"},
+		{"95-105", "ninety five to one hundred five"},
+		{"1.5-2.5", "one point five to two point five"},
+
+		// Dates that do not exist are left as written.
+		{"2023-02-30", "2023-02-30"},
+		{"2023-02-29", "2023-02-29"},
+		{"2024-02-29", "February twenty ninth, twenty twenty four"},
+		{"4/31/2023", "4/31/2023"},
+		{"13/13/2023", "13/13/2023"},
+		{"February 29", "February twenty ninth"},
+	}
+	p := NewPreprocessor()
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			if got := p.Process(tt.in); got != tt.want {
+				t.Errorf("Process(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProcessSampleText(t *testing.T) {
+	paths, err := filepath.Glob(filepath.Join("..", "..", "..", "..", "test", "sample-text-*"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(paths) == 0 {
+		t.Fatal("no sample texts found")
+	}
+
+	p := NewPreprocessor()
+	for _, path := range paths {
+		t.Run(filepath.Base(path), func(t *testing.T) {
+			data, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			// The samples are plain verse: nothing to expand, only
+			// whitespace to collapse.
+			want := strings.Join(strings.Fields(string(data)), " ")
+			if got := p.Process(string(data)); got != want {
+				t.Errorf("Process(%s) = %q, want %q", path, got, want)
+			}
+		})
+	}
+}
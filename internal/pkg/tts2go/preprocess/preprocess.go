@@ -2,6 +2,7 @@ package preprocess
 
 import (
 	"regexp"
+	"strconv"
 	"strings"
 	"unicode"
 
@@ -15,94 +16,177 @@ var (
 	emailRe      = regexp.MustCompile(`\S+@\S+\.\S+`)
 )
 
-type Preprocessor struct{}
+type Preprocessor struct {
+	dateOrder DateOrder
+}
 
 func NewPreprocessor() *Preprocessor {
-	return &Preprocessor{}
+	return &Preprocessor{dateOrder: DateMDY}
+}
+
+// SetDateOrder sets how ambiguous numeric dates are read.
+func (p *Preprocessor) SetDateOrder(order DateOrder) {
+	p.dateOrder = order
 }
 
 func (p *Preprocessor) Process(text string) string {
+	text, _ = p.ProcessExpansions(text)
+	return text
+}
+
+// ProcessExpansions is Process that also returns what it expanded, in
+// order, so that word timings can show the text as written.
+func (p *Preprocessor) ProcessExpansions(text string) (string, []Expansion) {
+	text = clean(text)
+	text, contractions := replace(text, contractionReplacements(text))
+	rules := p.englishRules().match(text)
+	text, expansions := replace(text, rules)
+	shift(contractions, rules)
+	all := wordIndexes(text, append(contractions, expansions...))
+	return finish(text), all
+}
+
+// Normalize cleans text without the English-specific expansion of
+// contractions, numbers, currency, times and ordinals.
+func (p *Preprocessor) Normalize(text string) string {
+	return finish(clean(text))
+}
+
+func clean(text string) string {
 	text = norm.NFC.String(text)
 	text = urlRe.ReplaceAllString(text, "")
 	text = htmlTagRe.ReplaceAllString(text, "")
 	text = emailRe.ReplaceAllString(text, "")
-	text = expandContractions(text)
-	text = expandNumbers(text)
-	text = expandCurrency(text)
-	text = expandTime(text)
-	text = expandOrdinals(text)
+	return text
+}
+
+func finish(text string) string {
 	text = normalizeQuotes(text)
 	text = normalizePunctuation(text)
 	text = whitespaceRe.ReplaceAllString(text, " ")
-	text = strings.TrimSpace(text)
+	return strings.TrimSpace(text)
+}
 
-	return text
+// irregularContractions are expanded as whole words.
+var irregularContractions = map[string]string{
+	"won't":  "will not",
+	"can't":  "cannot",
+	"shan't": "shall not",
+	"let's":  "let us",
+	"y'all":  "you all",
+}
+
+// contractionSuffixes are tried in order against the end of a word; the
+// rest of the word is expanded again, so stacked contractions ("wouldn't've")
+// expand fully.
+var contractionSuffixes = []struct {
+	suffix    string
+	expansion string
+}{
+	{"n't", " not"},
+	{"'re", " are"},
+	{"'ve", " have"},
+	{"'ll", " will"},
+	{"'m", " am"},
+	{"'d", " would"},
+	{"'s", " is"},
+}
+
+// sContractions are the words whose 's means "is" or "has"; on any other
+// word it is possessive and left alone.
+var sContractions = map[string]bool{
+	"he": true, "she": true, "it": true, "that": true, "this": true,
+	"there": true, "here": true, "what": true, "where": true, "who": true,
+	"when": true, "why": true, "how": true, "everyone": true, "everybody": true,
+	"someone": true, "somebody": true, "nobody": true, "nothing": true,
+	"something": true, "everything": true,
+}
+
+// participles after 's and 'd select "has" and "had" over "is" and "would".
+var participles = map[string]bool{
+	"been": true, "got": true, "gotten": true, "had": true, "done": true,
+	"gone": true, "seen": true, "made": true, "taken": true, "given": true,
+	"become": true, "said": true, "already": true,
 }
 
-var contractions = map[string]string{
-	"won't":     "will not",
-	"can't":     "cannot",
-	"n't":       " not",
-	"'re":       " are",
-	"'s":        " is",
-	"'d":        " would",
-	"'ll":       " will",
-	"'ve":       " have",
-	"'m":        " am",
-	"let's":     "let us",
-	"i'm":       "i am",
-	"you're":    "you are",
-	"he's":      "he is",
-	"she's":     "she is",
-	"it's":      "it is",
-	"we're":     "we are",
-	"they're":   "they are",
-	"i've":      "i have",
-	"you've":    "you have",
-	"we've":     "we have",
-	"they've":   "they have",
-	"i'd":       "i would",
-	"you'd":     "you would",
-	"he'd":      "he would",
-	"she'd":     "she would",
-	"we'd":      "we would",
-	"they'd":    "they would",
-	"i'll":      "i will",
-	"you'll":    "you will",
-	"he'll":     "he will",
-	"she'll":    "she will",
-	"we'll":     "we will",
-	"they'll":   "they will",
-	"isn't":     "is not",
-	"aren't":    "are not",
-	"wasn't":    "was not",
-	"weren't":   "were not",
-	"haven't":   "have not",
-	"hasn't":    "has not",
-	"hadn't":    "had not",
-	"doesn't":   "does not",
-	"don't":     "do not",
-	"didn't":    "did not",
-	"wouldn't":  "would not",
-	"shouldn't": "should not",
-	"couldn't":  "could not",
-	"mustn't":   "must not",
-	"shan't":    "shall not",
+var (
+	contractionRe = regexp.MustCompile(`[\p{L}\p{N}]+(?:['\x{2019}]\p{L}+)+`)
+	nextWordRe    = regexp.MustCompile(`^\s+(\p{L}+)`)
+)
+
+// contractionReplacements expands contractions word by word, keeping the
+// case of the original ("I'm" -> "I am", "DON'T" -> "DO NOT") and leaving
+// possessives ("John's") as they are.
+func contractionReplacements(text string) []replacement {
+	var replacements []replacement
+	for _, loc := range contractionRe.FindAllStringIndex(text, -1) {
+		word := text[loc[0]:loc[1]]
+		var next string
+		if m := nextWordRe.FindStringSubmatch(text[loc[1]:]); m != nil {
+			next = strings.ToLower(m[1])
+		}
+		if expanded := expandContraction(word, next); expanded != word {
+			replacements = append(replacements, replacement{loc[0], loc[1], expanded})
+		}
+	}
+	return replacements
 }
 
-func expandContractions(text string) string {
-	lower := strings.ToLower(text)
-	for contraction, expansion := range contractions {
-		lower = strings.ReplaceAll(lower, contraction, expansion)
+func expandContraction(word, next string) string {
+	normalized := strings.ReplaceAll(word, "\u2019", "'")
+	lower := strings.ToLower(normalized)
+
+	if expansion, ok := irregularContractions[lower]; ok {
+		return matchCase(expansion, word)
 	}
-	if len(text) > 0 && unicode.IsUpper(rune(text[0])) {
-		runes := []rune(lower)
-		if len(runes) > 0 {
-			runes[0] = unicode.ToUpper(runes[0])
+
+	for _, c := range contractionSuffixes {
+		stem, ok := strings.CutSuffix(lower, c.suffix)
+		if !ok || stem == "" {
+			continue
 		}
+		expansion := c.expansion
+		switch c.suffix {
+		case "'s":
+			if !sContractions[stem] {
+				return word
+			}
+			if participles[next] {
+				expansion = " has"
+			}
+		case "'d":
+			if participles[next] || next == "better" {
+				expansion = " had"
+			}
+		}
+		rest := expandContraction(normalized[:len(normalized)-len(c.suffix)], strings.TrimSpace(expansion))
+		return rest + matchCase(expansion, word)
+	}
+	return word
+}
+
+// matchCase uppercases expansion if original is all caps, and capitalizes
+// it if original is capitalized.
+func matchCase(expansion, original string) string {
+	upper, lower := 0, 0
+	for _, r := range original {
+		switch {
+		case unicode.IsUpper(r):
+			upper++
+		case unicode.IsLower(r):
+			lower++
+		}
+	}
+	switch {
+	case upper > 1 && lower == 0:
+		return strings.ToUpper(expansion)
+	case upper > 0 && unicode.IsUpper([]rune(original)[0]):
+		runes := []rune(expansion)
+		runes[0] = unicode.ToUpper(runes[0])
 		return string(runes)
+	default:
+		return expansion
 	}
-	return lower
 }
 
 var onesWords = []string{
@@ -115,40 +199,58 @@ var tensWords = []string{
 	"", "", "twenty", "thirty", "forty", "fifty", "sixty", "seventy", "eighty", "ninety",
 }
 
-var scaleWords = []string{"", "thousand", "million", "billion", "trillion"}
+var scaleWords = []string{
+	"", "thousand", "million", "billion", "trillion", "quadrillion",
+	"quintillion", "sextillion", "septillion", "octillion", "nonillion",
+	"decillion",
+}
 
 func numberToWords(n int64) string {
-	if n == 0 {
-		return "zero"
+	if n < 0 {
+		// Negate as unsigned so that math.MinInt64 survives.
+		return "negative " + digitsToWords(strconv.FormatUint(-uint64(n), 10))
 	}
+	return digitsToWords(strconv.FormatInt(n, 10))
+}
 
-	negative := false
-	if n < 0 {
-		negative = true
-		n = -n
+// digitsToWords reads a string of decimal digits as a cardinal number.
+// Numbers too large for the scale words are read digit by digit.
+func digitsToWords(digits string) string {
+	digits = strings.TrimLeft(digits, "0")
+	if digits == "" {
+		return "zero"
+	}
+	if (len(digits)+2)/3 > len(scaleWords) {
+		return digitsByOne(digits)
 	}
 
 	var parts []string
-	scaleIndex := 0
-
-	for n > 0 {
-		chunk := n % 1000
+	for scaleIndex := 0; digits != ""; scaleIndex++ {
+		start := max(len(digits)-3, 0)
+		chunk, _ := strconv.Atoi(digits[start:])
+		digits = digits[:start]
 		if chunk > 0 {
-			chunkWords := chunkToWords(int(chunk))
-			if scaleIndex > 0 && scaleIndex < len(scaleWords) {
+			chunkWords := chunkToWords(chunk)
+			if scaleIndex > 0 {
 				chunkWords += " " + scaleWords[scaleIndex]
 			}
 			parts = append([]string{chunkWords}, parts...)
 		}
-		n /= 1000
-		scaleIndex++
 	}
+	return strings.Join(parts, " ")
+}
 
-	result := strings.Join(parts, " ")
-	if negative {
-		result = "negative " + result
+// digitsByOne reads each digit on its own ("one four").
+func digitsByOne(digits string) string {
+	words := make([]string, 0, len(digits))
+	for _, c := range digits {
+		if c == '0' {
+			words = append(words, "zero")
+		} else {
+			words = append(words, onesWords[c-'0'])
+		}
 	}
-	return result
+	return strings.Join(words, " ")
 }
 
 func chunkToWords(n int) string {
@@ -174,125 +276,6 @@ func chunkToWords(n int) string {
 	return hundreds + " " + chunkToWords(remainder)
 }
 
-var numberRe = regexp.MustCompile(`\b(\d{1,15})\b`)
-
-func expandNumbers(text string) string {
-	return numberRe.ReplaceAllStringFunc(text, func(match string) string {
-		var n int64
-		for _, c := range match {
-			n = n*10 + int64(c-'0')
-		}
-		return numberToWords(n)
-	})
-}
-
-var currencyRe = regexp.MustCompile(`\$(\d+(?:\.\d{2})?)`)
-
-func expandCurrency(text string) string {
-	return currencyRe.ReplaceAllStringFunc(text, func(match string) string {
-		match = strings.TrimPrefix(match, "$")
-		parts := strings.Split(match, ".")
-		var n int64
-		for _, c := range parts[0] {
-			n = n*10 + int64(c-'0')
-		}
-		result := numberToWords(n)
-		if n == 1 {
-			result += " dollar"
-		} else {
-			result += " dollars"
-		}
-		if len(parts) == 2 && parts[1] != "00" {
-			var cents int64
-			for _, c := range parts[1] {
-				cents = cents*10 + int64(c-'0')
-			}
-			result += " and " + numberToWords(cents)
-			if cents == 1 {
-				result += " cent"
-			} else {
-				result += " cents"
-			}
-		}
-		return result
-	})
-}
-
-var timeRe = regexp.MustCompile(`\b(\d{1,2}):(\d{2})\s*(am|pm|AM|PM)?\b`)
-
-func expandTime(text string) string {
-	return timeRe.ReplaceAllStringFunc(text, func(match string) string {
-		parts := timeRe.FindStringSubmatch(match)
-		if len(parts) < 3 {
-			return match
-		}
-		var hour, minute int64
-		for _, c := range parts[1] {
-			hour = hour*10 + int64(c-'0')
-		}
-		for _, c := range parts[2] {
-			minute = minute*10 + int64(c-'0')
-		}
-		var result string
-		result = numberToWords(hour)
-		if minute == 0 {
-			if len(parts) > 3 && parts[3] != "" {
-				result += " " + strings.ToLower(parts[3])
-			} else {
-				result += " o'clock"
-			}
-		} else if minute < 10 {
-			result += " oh " + numberToWords(minute)
-		} else {
-			result += " " + numberToWords(minute)
-		}
-		if len(parts) > 3 && parts[3] != "" && minute != 0 {
-			result += " " + strings.ToLower(parts[3])
-		}
-		return result
-	})
-}
-
-var ordinalRe = regexp.MustCompile(`\b(\d+)(st|nd|rd|th)\b`)
-
-var ordinalWords = map[int64]string{
-	1: "first", 2: "second", 3: "third", 4: "fourth", 5: "fifth",
-	6: "sixth", 7: "seventh", 8: "eighth", 9: "ninth", 10: "tenth",
-	11: "eleventh", 12: "twelfth", 13: "thirteenth", 14: "fourteenth",
-	15: "fifteenth", 16: "sixteenth", 17: "seventeenth", 18: "eighteenth",
-	19: "nineteenth", 20: "twentieth", 30: "thirtieth", 40: "fortieth",
-	50: "fiftieth", 60: "sixtieth", 70: "seventieth", 80: "eightieth",
-	90: "ninetieth",
-}
-
-func expandOrdinals(text string) string {
-	return ordinalRe.ReplaceAllStringFunc(text, func(match string) string {
-		parts := ordinalRe.FindStringSubmatch(match)
-		if len(parts) < 2 {
-			return match
-		}
-		var n int64
-		for _, c := range parts[1] {
-			n = n*10 + int64(c-'0')
-		}
-		if word, ok := ordinalWords[n]; ok {
-			return word
-		}
-		if n > 20 && n < 100 {
-			tens := (n / 10) * 10
-			ones := n % 10
-			if ones == 0 {
-				if word, ok := ordinalWords[tens]; ok {
-					return word
-				}
-			} else if word, ok := ordinalWords[ones]; ok {
-				return tensWords[n/10] + " " + word
-			}
-		}
-		return numberToWords(n) + "th"
-	})
-}
-
 func normalizeQuotes(text string) string {
 	text = strings.ReplaceAll(text, "\u201c", "\"")
 	text = strings.ReplaceAll(text, "\u201d", "\"")
@@ -0,0 +1,52 @@
+package preprocess
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	sentenceEndRe = regexp.MustCompile(`[.!?]+["')\]]*\s+`)
+	clauseEndRe   = regexp.MustCompile(`[,;:]\s+`)
+)
+
+func SplitSentences(text string) []string {
+	return splitAfter(text, sentenceEndRe)
+}
+
+func SplitClauses(text string) []string {
+	return splitAfter(text, clauseEndRe)
+}
+
+func SplitWords(text string, n int) []string {
+	words := strings.Fields(text)
+	if n <= 0 || len(words) <= n {
+		return []string{strings.Join(words, " ")}
+	}
+
+	var parts []string
+	for len(words) > 0 {
+		end := n
+		if end > len(words) {
+			end = len(words)
+		}
+		parts = append(parts, strings.Join(words[:end], " "))
+		words = words[end:]
+	}
+	return parts
+}
+
+func splitAfter(text string, re *regexp.Regexp) []string {
+	var parts []string
+	start := 0
+	for _, loc := range re.FindAllStringIndex(text, -1) {
+		if part := strings.TrimSpace(text[start:loc[1]]); part != "" {
+			parts = append(parts, part)
+		}
+		start = loc[1]
+	}
+	if part := strings.TrimSpace(text[start:]); part != "" {
+		parts = append(parts, part)
+	}
+	return parts
+}
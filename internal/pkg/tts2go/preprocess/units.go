@@ -0,0 +1,123 @@
+package preprocess
+
+import (
+	"cmp"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+type unit struct {
+	singular, plural string
+}
+
+// units are the measurement abbreviations read after a number. Ambiguous
+// ones that are also common words ("in", "s") are left out, and single
+// letters are only read when written against the number ("2m", not "2 m").
+var units = map[string]unit{
+	"km":   {"kilometer", "kilometers"},
+	"m":    {"meter", "meters"},
+	"cm":   {"centimeter", "centimeters"},
+	"mm":   {"millimeter", "millimeters"},
+	"mi":   {"mile", "miles"},
+	"ft":   {"foot", "feet"},
+	"kg":   {"kilogram", "kilograms"},
+	"g":    {"gram", "grams"},
+	"mg":   {"milligram", "milligrams"},
+	"lb":   {"pound", "pounds"},
+	"lbs":  {"pound", "pounds"},
+	"oz":   {"ounce", "ounces"},
+	"l":    {"liter", "liters"},
+	"L":    {"liter", "liters"},
+	"ml":   {"milliliter", "milliliters"},
+	"mL":   {"milliliter", "milliliters"},
+	"°C":   {"degree Celsius", "degrees Celsius"},
+	"°F":   {"degree Fahrenheit", "degrees Fahrenheit"},
+	"°":    {"degree", "degrees"},
+	"mph":  {"mile per hour", "miles per hour"},
+	"km/h": {"kilometer per hour", "kilometers per hour"},
+	"kph":  {"kilometer per hour", "kilometers per hour"},
+	"m/s":  {"meter per second", "meters per second"},
+	"KB":   {"kilobyte", "kilobytes"},
+	"MB":   {"megabyte", "megabytes"},
+	"GB":   {"gigabyte", "gigabytes"},
+	"TB":   {"terabyte", "terabytes"},
+	"kbps": {"kilobit per second", "kilobits per second"},
+	"Mbps": {"megabit per second", "megabits per second"},
+	"Gbps": {"gigabit per second", "gigabits per second"},
+	"Hz":   {"hertz", "hertz"},
+	"kHz":  {"kilohertz", "kilohertz"},
+	"MHz":  {"megahertz", "megahertz"},
+	"GHz":  {"gigahertz", "gigahertz"},
+	"W":    {"watt", "watts"},
+	"kW":   {"kilowatt", "kilowatts"},
+	"kWh":  {"kilowatt hour", "kilowatt hours"},
+	"V":    {"volt", "volts"},
+	"ms":   {"millisecond", "milliseconds"},
+	"sec":  {"second", "seconds"},
+	"min":  {"minute", "minutes"},
+	"h":    {"hour", "hours"},
+	"hr":   {"hour", "hours"},
+	"hrs":  {"hour", "hours"},
+}
+
+// unitRe matches a number or range followed by a unit: "5 km", "-3°C",
+// "1.5GB", "5-10 kg", "20 to 25 °C".
+var unitRe = regexp.MustCompile(`([-+\x{2212}]?)` +
+	`(?:(` + integerPattern + `)((?:\.\d+)?)(?:-|\s*\x{2013}\s*|\s+to\s+))?` +
+	`(` + integerPattern + `|)((?:\.\d+)?)\s?(` + unitPattern() + `)`)
+
+// unitPattern lists the units longest first, so "mph" is tried before "m".
+func unitPattern() string {
+	names := make([]string, 0, len(units))
+	for name := range units {
+		names = append(names, regexp.QuoteMeta(name))
+	}
+	slices.SortFunc(names, func(a, b string) int {
+		if c := cmp.Compare(len(b), len(a)); c != 0 {
+			return c
+		}
+		return strings.Compare(a, b)
+	})
+	return strings.Join(names, "|")
+}
+
+func expandUnit(text string, m []int) (int, int, string, bool) {
+	if group(text, m, 4) == "" && group(text, m, 5) == "" {
+		return 0, 0, "", false
+	}
+	start := m[0]
+	sign := group(text, m, 1)
+	if sign != "" && start > 0 {
+		before, _ := utf8.DecodeLastRuneInString(text[:start])
+		if isWordChar(before) {
+			start = m[3]
+			sign = ""
+		}
+	}
+	if !standalone(text, start+len(sign), m[1]) {
+		return 0, 0, "", false
+	}
+	symbol := group(text, m, 6)
+	if m[12] > m[11] && isSingleLetter(symbol) {
+		return 0, 0, "", false
+	}
+
+	integer, decimals := group(text, m, 4), group(text, m, 5)
+	u := units[symbol]
+	spoken := signWord(sign)
+	name := u.plural
+	if from := group(text, m, 2); from != "" {
+		spoken += readNumber(from, group(text, m, 3)) + " to "
+	} else if decimals == "" {
+		name = pluralForm(groupSeparators.Replace(integer), u.singular, u.plural)
+	}
+	return start, m[1], spoken + readNumber(integer, decimals) + " " + name, true
+}
+
+func isSingleLetter(s string) bool {
+	r, size := utf8.DecodeRuneInString(s)
+	return size == len(s) && unicode.IsLetter(r)
+}
@@ -0,0 +1,103 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"tts2go/internal/pkg/tts2go/audio"
+)
+
+// speechRequest mirrors the OpenAI /v1/audio/speech request body. The model
+// and instructions fields are accepted for compatibility and ignored.
+type speechRequest struct {
+	Model          string   `json:"model"`
+	Input          string   `json:"input"`
+	Voice          string   `json:"voice"`
+	Speed          *float32 `json:"speed"`
+	ResponseFormat string   `json:"response_format"`
+	Instructions   string   `json:"instructions"`
+}
+
+type openAIError struct {
+	Message string  `json:"message"`
+	Type    string  `json:"type"`
+	Param   *string `json:"param"`
+	Code    *string `json:"code"`
+}
+
+// openAIFallbackFormats maps OpenAI's compressed formats, which tts2go
+// cannot encode, to the format returned instead.
+var openAIFallbackFormats = map[string]string{
+	"mp3":  audio.FormatWAV,
+	"opus": audio.FormatWAV,
+	"aac":  audio.FormatWAV,
+}
+
+func (s *Server) handleSpeech(w http.ResponseWriter, r *http.Request) {
+	var req speechRequest
+	r.Body = http.MaxBytesReader(w, r.Body, maxRequestBytes)
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeOpenAIError(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err), "")
+		return
+	}
+
+	if strings.TrimSpace(req.Input) == "" {
+		writeOpenAIError(w, http.StatusBadRequest, fmt.Errorf("input is required"), "input")
+		return
+	}
+
+	// OpenAI accepts 0.25-4.0; clamp into the range the models support.
+	var speed float32
+	if req.Speed != nil {
+		speed = min(max(*req.Speed, 0.5), 2.0)
+	}
+
+	format := strings.ToLower(req.ResponseFormat)
+	if fallback, ok := openAIFallbackFormats[format]; ok {
+		s.log.Debug().Str("format", format).Str("fallback", fallback).Msg("Unsupported response format, using fallback")
+		format = fallback
+	}
+	if _, _, err := outputFormat(format); err != nil {
+		writeOpenAIError(w, http.StatusBadRequest, err, "response_format")
+		return
+	}
+
+	data, contentType, err := s.synthesize(req.Input, s.resolveVoice(req.Voice), speed, format)
+	if err != nil {
+		writeOpenAIError(w, statusFor(err), err, "")
+		return
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Length", fmt.Sprint(len(data)))
+	w.Write(data)
+}
+
+// resolveVoice maps a client voice name to a local one: local voices are
+// used as-is, then aliases are consulted, then the server default applies.
+func (s *Server) resolveVoice(name string) string {
+	if name == "" || s.hasVoice(name) {
+		return name
+	}
+	if alias, ok := s.defaults.VoiceAliases[strings.ToLower(name)]; ok {
+		return alias
+	}
+	s.log.Debug().Str("voice", name).Msg("Unknown voice, using default")
+	return ""
+}
+
+func writeOpenAIError(w http.ResponseWriter, status int, err error, param string) {
+	e := openAIError{
+		Message: err.Error(),
+		Type:    "invalid_request_error",
+	}
+	if status >= http.StatusInternalServerError {
+		e.Type = "server_error"
+	}
+	if param != "" {
+		e.Param = &param
+	}
+	writeJSON(w, status, map[string]openAIError{"error": e})
+}
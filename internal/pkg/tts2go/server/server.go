@@ -0,0 +1,264 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog"
+
+	"tts2go/internal/pkg/tts2go/audio"
+)
+
+const maxRequestBytes = 1 << 20
+
+type Synthesizer interface {
+	Generate(text, voiceName string, speed float32) (*audio.Audio, error)
+	ListVoices() []string
+}
+
+type Defaults struct {
+	Voice string
+	Speed float32
+	// VoiceAliases maps foreign voice names (e.g. OpenAI's "alloy") to
+	// local voices.
+	VoiceAliases map[string]string
+	// SampleRate resamples every response to this rate; 0 keeps the
+	// model's native rate.
+	SampleRate int
+	// Loudness normalizes every response to this integrated loudness in
+	// LUFS, limited to TruePeak dBTP; 0 disables normalization.
+	Loudness float64
+	TruePeak float64
+}
+
+// Server exposes a loaded Synthesizer over HTTP. Generation is serialized,
+// so the model is loaded once and shared by all requests.
+type Server struct {
+	tts      Synthesizer
+	defaults Defaults
+	log      zerolog.Logger
+	mu       sync.Mutex
+	mux      *http.ServeMux
+}
+
+func New(tts Synthesizer, defaults Defaults, logger zerolog.Logger) *Server {
+	if defaults.Speed == 0 {
+		defaults.Speed = 1.0
+	}
+
+	s := &Server{
+		tts:      tts,
+		defaults: defaults,
+		log:      logger,
+		mux:      http.NewServeMux(),
+	}
+
+	s.mux.HandleFunc("POST /synthesize", s.handleSynthesize)
+	s.mux.HandleFunc("GET /voices", s.handleVoices)
+	s.mux.HandleFunc("GET /health", s.handleHealth)
+	s.mux.HandleFunc("POST /v1/audio/speech", s.handleSpeech)
+
+	return s
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+	s.mux.ServeHTTP(rec, r)
+	s.log.Info().
+		Str("method", r.Method).
+		Str("path", r.URL.Path).
+		Int("status", rec.status).
+		Dur("elapsed", time.Since(start)).
+		Msg("Request handled")
+}
+
+type synthesizeRequest struct {
+	Text   string  `json:"text"`
+	Voice  string  `json:"voice"`
+	Speed  float32 `json:"speed"`
+	Format string  `json:"format"`
+}
+
+func (s *Server) handleSynthesize(w http.ResponseWriter, r *http.Request) {
+	var req synthesizeRequest
+	if err := decodeJSON(w, r, &req); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	if strings.TrimSpace(req.Text) == "" {
+		writeError(w, http.StatusBadRequest, errors.New("text is required"))
+		return
+	}
+
+	data, contentType, err := s.synthesize(req.Text, req.Voice, req.Speed, req.Format)
+	if err != nil {
+		writeError(w, statusFor(err), err)
+		return
+	}
+
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Length", fmt.Sprint(len(data)))
+	w.Write(data)
+}
+
+func (s *Server) synthesize(text, voiceName string, speed float32, format string) ([]byte, string, error) {
+	if voiceName == "" {
+		voiceName = s.defaultVoice()
+	}
+	if speed == 0 {
+		speed = s.defaults.Speed
+	}
+	if speed < 0.5 || speed > 2.0 {
+		return nil, "", badRequest("speed must be between 0.5 and 2.0")
+	}
+
+	if !s.hasVoice(voiceName) {
+		return nil, "", badRequest(fmt.Sprintf("voice not found: %s", voiceName))
+	}
+
+	format, contentType, err := outputFormat(format)
+	if err != nil {
+		return nil, "", err
+	}
+
+	s.mu.Lock()
+	a, err := s.tts.Generate(text, voiceName, speed)
+	s.mu.Unlock()
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to generate audio: %w", err)
+	}
+	sampleRate := s.defaults.SampleRate
+	if sampleRate == 0 {
+		sampleRate = audio.FormatSampleRate(format, audio.WriteOptions{})
+	}
+	a = a.Resample(sampleRate)
+	if s.defaults.Loudness != 0 {
+		a = a.NormalizeLoudness(s.defaults.Loudness, s.defaults.TruePeak)
+	}
+
+	var buf bytes.Buffer
+	if err := a.Encode(&buf, format, audio.WriteOptions{}); err != nil {
+		return nil, "", fmt.Errorf("failed to encode audio: %w", err)
+	}
+	return buf.Bytes(), contentType, nil
+}
+
+func (s *Server) handleVoices(w http.ResponseWriter, r *http.Request) {
+	voices := s.tts.ListVoices()
+	sort.Strings(voices)
+	writeJSON(w, http.StatusOK, map[string]any{"voices": voices})
+}
+
+func (s *Server) handleHealth(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+func (s *Server) defaultVoice() string {
+	if s.defaults.Voice != "" {
+		return s.defaults.Voice
+	}
+	voices := s.tts.ListVoices()
+	if len(voices) == 0 {
+		return ""
+	}
+	sort.Strings(voices)
+	return voices[0]
+}
+
+func (s *Server) hasVoice(name string) bool {
+	for _, v := range s.tts.ListVoices() {
+		if v == name {
+			return true
+		}
+	}
+	return false
+}
+
+var contentTypes = map[string]string{
+	audio.FormatWAV:  "audio/wav",
+	audio.FormatPCM:  "audio/pcm",
+	audio.FormatULaw: "audio/basic",
+	audio.FormatALaw: "audio/x-alaw-basic",
+	audio.FormatFLAC: "audio/flac",
+}
+
+func outputFormat(format string) (string, string, error) {
+	format = strings.ToLower(format)
+	if format == "" {
+		format = audio.FormatWAV
+	}
+	contentType, ok := contentTypes[format]
+	if !ok {
+		return "", "", badRequest(fmt.Sprintf("unsupported format: %s (supported: %s)",
+			format, strings.Join(supportedFormats(), ", ")))
+	}
+	return format, contentType, nil
+}
+
+func supportedFormats() []string {
+	formats := make([]string, 0, len(contentTypes))
+	for f := range contentTypes {
+		formats = append(formats, f)
+	}
+	sort.Strings(formats)
+	return formats
+}
+
+type requestError struct {
+	msg string
+}
+
+func (e *requestError) Error() string {
+	return e.msg
+}
+
+func badRequest(msg string) error {
+	return &requestError{msg: msg}
+}
+
+func statusFor(err error) int {
+	var reqErr *requestError
+	if errors.As(err, &reqErr) {
+		return http.StatusBadRequest
+	}
+	return http.StatusInternalServerError
+}
+
+func decodeJSON(w http.ResponseWriter, r *http.Request, v any) error {
+	r.Body = http.MaxBytesReader(w, r.Body, maxRequestBytes)
+	dec := json.NewDecoder(r.Body)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		return fmt.Errorf("invalid request body: %w", err)
+	}
+	return nil
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
+
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
@@ -0,0 +1,339 @@
+package ssml
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Segment is a run of input spoken with one voice and rate, or a pause.
+type Segment struct {
+	Text string
+	// Phonemes, if set, is IPA spoken instead of phonemizing Text.
+	Phonemes string
+	// Voice is the voice name, or "" for the caller's voice.
+	Voice string
+	// Rate multiplies the caller's speed.
+	Rate float32
+	// Break is silence to insert; a break segment has no text.
+	Break time.Duration
+}
+
+func (s Segment) IsBreak() bool {
+	return s.Text == "" && s.Phonemes == ""
+}
+
+// IsSSML reports whether text is an SSML document rather than plain text.
+func IsSSML(text string) bool {
+	text = strings.TrimSpace(text)
+	if strings.HasPrefix(text, "<?xml") {
+		if end := strings.Index(text, "?>"); end >= 0 {
+			text = strings.TrimSpace(text[end+2:])
+		}
+	}
+	return strings.HasPrefix(text, "<speak")
+}
+
+var breakStrengths = map[string]time.Duration{
+	"none":     0,
+	"x-weak":   100 * time.Millisecond,
+	"weak":     250 * time.Millisecond,
+	"medium":   400 * time.Millisecond,
+	"strong":   750 * time.Millisecond,
+	"x-strong": 1200 * time.Millisecond,
+}
+
+var rateNames = map[string]float32{
+	"x-slow":  0.5,
+	"slow":    0.75,
+	"medium":  1,
+	"default": 1,
+	"fast":    1.25,
+	"x-fast":  1.5,
+}
+
+type scope struct {
+	voice string
+	rate  float32
+}
+
+type parser struct {
+	segments []Segment
+	scopes   []scope
+	text     strings.Builder
+
+	// capture collects the content of say-as, sub and phoneme elements,
+	// which replace it when they close.
+	capture      *strings.Builder
+	captureDepth int
+	captureAttrs map[string]string
+}
+
+// Parse converts an SSML document into segments. It supports speak, break,
+// prosody (rate), voice (name), say-as, phoneme (IPA) and sub; other
+// elements are ignored but their text is kept.
+func Parse(document string) ([]Segment, error) {
+	d := xml.NewDecoder(strings.NewReader(document))
+	d.Entity = xml.HTMLEntity
+
+	p := &parser{scopes: []scope{{rate: 1}}}
+	for {
+		tok, err := d.Token()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse SSML: %w", err)
+		}
+
+		switch tok := tok.(type) {
+		case xml.StartElement:
+			if err := p.start(tok); err != nil {
+				return nil, err
+			}
+		case xml.EndElement:
+			p.end(tok.Name.Local)
+		case xml.CharData:
+			if p.capture != nil {
+				p.capture.Write(tok)
+			} else {
+				p.text.Write(tok)
+			}
+		}
+	}
+	p.flush()
+	return mergeBreaks(p.segments), nil
+}
+
+func (p *parser) current() scope {
+	return p.scopes[len(p.scopes)-1]
+}
+
+func (p *parser) start(el xml.StartElement) error {
+	attrs := make(map[string]string, len(el.Attr))
+	for _, a := range el.Attr {
+		attrs[a.Name.Local] = a.Value
+	}
+	name := el.Name.Local
+
+	if p.capture != nil {
+		p.captureDepth++
+		return nil
+	}
+
+	s := p.current()
+	switch name {
+	case "break":
+		d, err := breakDuration(attrs)
+		if err != nil {
+			return err
+		}
+		p.flush()
+		p.segments = append(p.segments, Segment{Break: d})
+	case "prosody":
+		if rate, ok := attrs["rate"]; ok {
+			r, err := parseRate(rate)
+			if err != nil {
+				return err
+			}
+			p.flush()
+			s.rate *= r
+		}
+	case "voice":
+		if voice := attrs["name"]; voice != "" {
+			p.flush()
+			s.voice = voice
+		}
+	case "say-as", "sub", "phoneme":
+		if name == "phoneme" {
+			if alphabet := attrs["alphabet"]; alphabet != "" && alphabet != "ipa" {
+				return fmt.Errorf("unsupported phoneme alphabet: %s (want ipa)", alphabet)
+			}
+		}
+		p.capture = &strings.Builder{}
+		p.captureAttrs = attrs
+	case "p", "s":
+		p.text.WriteString(" ")
+	}
+	p.scopes = append(p.scopes, s)
+	return nil
+}
+
+func (p *parser) end(name string) {
+	if p.capture != nil && p.captureDepth > 0 {
+		p.captureDepth--
+		return
+	}
+	if name == "prosody" || name == "voice" {
+		p.flush()
+	}
+	if len(p.scopes) > 1 {
+		p.scopes = p.scopes[:len(p.scopes)-1]
+	}
+
+	switch name {
+	case "say-as":
+		p.text.WriteString(sayAs(p.capture.String(), p.captureAttrs["interpret-as"]))
+		p.capture = nil
+	case "sub":
+		alias, ok := p.captureAttrs["alias"]
+		if !ok {
+			alias = p.capture.String()
+		}
+		p.text.WriteString(alias)
+		p.capture = nil
+	case "phoneme":
+		text := collapse(p.capture.String())
+		ph := strings.TrimSpace(p.captureAttrs["ph"])
+		p.capture = nil
+		if ph == "" {
+			p.text.WriteString(text)
+			return
+		}
+		// Spoken on its own so that the IPA bypasses the phonemizer; the
+		// parent's voice and rate still apply.
+		p.flush()
+		s := p.current()
+		p.segments = append(p.segments, Segment{Text: text, Phonemes: ph, Voice: s.voice, Rate: s.rate})
+	case "p", "s":
+		p.text.WriteString(" ")
+	}
+}
+
+// flush ends the pending text segment.
+func (p *parser) flush() {
+	text := collapse(p.text.String())
+	p.text.Reset()
+	if text == "" {
+		return
+	}
+	s := p.current()
+	p.segments = append(p.segments, Segment{Text: text, Voice: s.voice, Rate: s.rate})
+}
+
+func mergeBreaks(segments []Segment) []Segment {
+	var merged []Segment
+	for _, s := range segments {
+		if n := len(merged); n > 0 && s.IsBreak() && merged[n-1].IsBreak() {
+			merged[n-1].Break += s.Break
+			continue
+		}
+		merged = append(merged, s)
+	}
+	return merged
+}
+
+func breakDuration(attrs map[string]string) (time.Duration, error) {
+	if t, ok := attrs["time"]; ok {
+		d, err := time.ParseDuration(strings.TrimSpace(t))
+		if err != nil || d < 0 {
+			return 0, fmt.Errorf("invalid break time: %q", t)
+		}
+		return d, nil
+	}
+	strength := attrs["strength"]
+	if strength == "" {
+		strength = "medium"
+	}
+	d, ok := breakStrengths[strength]
+	if !ok {
+		return 0, fmt.Errorf("invalid break strength: %q", strength)
+	}
+	return d, nil
+}
+
+// parseRate accepts a rate name, a percentage of the current rate ("150%",
+// "+20%", "-10%") or a plain multiplier ("1.2").
+func parseRate(rate string) (float32, error) {
+	rate = strings.TrimSpace(rate)
+	if r, ok := rateNames[rate]; ok {
+		return r, nil
+	}
+
+	var r float64
+	var err error
+	if pct, ok := strings.CutSuffix(rate, "%"); ok {
+		r, err = strconv.ParseFloat(pct, 64)
+		if strings.HasPrefix(pct, "+") || strings.HasPrefix(pct, "-") {
+			r += 100
+		}
+		r /= 100
+	} else {
+		r, err = strconv.ParseFloat(rate, 64)
+	}
+	if err != nil || r <= 0 {
+		return 0, fmt.Errorf("invalid prosody rate: %q", rate)
+	}
+	return float32(r), nil
+}
+
+// sayAs rewrites text so that the normalizer and phonemizer read it as
+// interpretAs says.
+func sayAs(text, interpretAs string) string {
+	text = collapse(text)
+	switch interpretAs {
+	case "characters", "spell-out", "verbatim":
+		return spellOut(text)
+	case "digits", "telephone":
+		var b strings.Builder
+		for _, r := range text {
+			switch {
+			case unicode.IsDigit(r):
+				b.WriteRune(r)
+				b.WriteString(" ")
+			case r == '+':
+				b.WriteString("plus ")
+			case unicode.IsSpace(r):
+				b.WriteRune(r)
+			default:
+				b.WriteString(", ")
+			}
+		}
+		return strings.ReplaceAll(collapse(b.String()), " ,", ",")
+	case "cardinal", "number":
+		return strings.ReplaceAll(text, ",", "")
+	case "ordinal":
+		digits := strings.ReplaceAll(text, ",", "")
+		if n, err := strconv.ParseInt(digits, 10, 64); err == nil && n >= 0 {
+			return digits + ordinalSuffix(n)
+		}
+		return text
+	default:
+		return text
+	}
+}
+
+func spellOut(text string) string {
+	var letters []string
+	for _, r := range text {
+		if !unicode.IsSpace(r) {
+			letters = append(letters, string(r))
+		}
+	}
+	return strings.Join(letters, " ")
+}
+
+func ordinalSuffix(n int64) string {
+	if n%100 >= 11 && n%100 <= 13 {
+		return "th"
+	}
+	switch n % 10 {
+	case 1:
+		return "st"
+	case 2:
+		return "nd"
+	case 3:
+		return "rd"
+	default:
+		return "th"
+	}
+}
+
+func collapse(text string) string {
+	return strings.Join(strings.Fields(text), " ")
+}
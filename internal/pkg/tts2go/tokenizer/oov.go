@@ -0,0 +1,157 @@
+package tokenizer
+
+import (
+	"fmt"
+	"strings"
+)
+
+// OOVPolicy controls what Encode does with phonemes missing from the vocab.
+type OOVPolicy string
+
+const (
+	OOVDrop     OOVPolicy = "drop"
+	OOVFallback OOVPolicy = "fallback"
+	OOVError    OOVPolicy = "error"
+)
+
+func ParseOOVPolicy(s string) (OOVPolicy, error) {
+	switch p := OOVPolicy(strings.ToLower(s)); p {
+	case "":
+		return OOVDrop, nil
+	case OOVDrop, OOVFallback, OOVError:
+		return p, nil
+	default:
+		return "", fmt.Errorf("unknown OOV policy %q (expected drop, fallback or error)", s)
+	}
+}
+
+// fallbackSymbols maps IPA symbols that some vocabularies lack to the
+// nearest spelling other vocabularies use. An empty mapping drops the
+// symbol deliberately.
+var fallbackSymbols = map[rune]string{
+	'ɝ':      "ɜɹ",
+	'ɚ':      "əɹ",
+	'ʤ':      "dʒ",
+	'ʧ':      "tʃ",
+	'ʦ':      "ts",
+	'ʣ':      "dz",
+	'ɫ':      "l",
+	'ɡ':      "g",
+	'g':      "ɡ",
+	'r':      "ɹ",
+	'ɹ':      "r",
+	'ɐ':      "ə",
+	'ᵻ':      "ɪ",
+	'ɜ':      "ə",
+	'ɒ':      "ɑ",
+	'e':      "ɛ",
+	'o':      "ɔ",
+	'ɾ':      "t",
+	'ˑ':      "ː",
+	'ʲ':      "",
+	'ʔ':      "",
+	'\u0303': "",
+	'\u0329': "",
+	'‿':      " ",
+	'-':      " ",
+	'–':      ",",
+	'—':      ",",
+	'“':      "\"",
+	'”':      "\"",
+	'’':      "'",
+}
+
+// Unknown is a phoneme the vocabulary has no id for.
+type Unknown struct {
+	Rune rune
+	// Pos is the rune offset of the phoneme in the encoded text.
+	Pos int
+	// Mapped is the replacement used under OOVFallback; empty if dropped.
+	Mapped string
+}
+
+func (u Unknown) String() string {
+	if u.Mapped != "" {
+		return fmt.Sprintf("%q@%d->%q", u.Rune, u.Pos, u.Mapped)
+	}
+	return fmt.Sprintf("%q@%d", u.Rune, u.Pos)
+}
+
+type UnknownSymbolsError struct {
+	Unknowns []Unknown
+}
+
+func (e *UnknownSymbolsError) Error() string {
+	parts := make([]string, len(e.Unknowns))
+	for i, u := range e.Unknowns {
+		parts[i] = u.String()
+	}
+	return fmt.Sprintf("unknown phonemes: %s", strings.Join(parts, ", "))
+}
+
+func (t *Tokenizer) SetOOVPolicy(p OOVPolicy) {
+	t.oovPolicy = p
+}
+
+func (t *Tokenizer) OOVPolicy() OOVPolicy {
+	if t.oovPolicy == "" {
+		return OOVDrop
+	}
+	return t.oovPolicy
+}
+
+// EncodeChecked is Encode with the tokenizer's OOV policy applied; it
+// reports every phoneme missing from the vocabulary.
+func (t *Tokenizer) EncodeChecked(text string) ([]int64, []Unknown, error) {
+	ids, unknowns, err := t.EncodePhonemesChecked(text)
+	if err != nil {
+		return nil, unknowns, err
+	}
+	return t.Frame(ids), unknowns, nil
+}
+
+// EncodePhonemesChecked is EncodePhonemes with the OOV policy applied.
+func (t *Tokenizer) EncodePhonemesChecked(text string) ([]int64, []Unknown, error) {
+	policy := t.OOVPolicy()
+	ids := make([]int64, 0, len(text))
+	var unknowns []Unknown
+
+	pos := 0
+	for _, r := range text {
+		if idx, ok := t.symbolToIndex[r]; ok {
+			ids = append(ids, idx)
+			pos++
+			continue
+		}
+
+		u := Unknown{Rune: r, Pos: pos}
+		if policy == OOVFallback {
+			if mapped, ok := t.fallback(r); ok {
+				u.Mapped = mapped
+				for _, m := range mapped {
+					ids = append(ids, t.symbolToIndex[m])
+				}
+			}
+		}
+		unknowns = append(unknowns, u)
+		pos++
+	}
+
+	if policy == OOVError && len(unknowns) > 0 {
+		return nil, unknowns, &UnknownSymbolsError{Unknowns: unknowns}
+	}
+	return ids, unknowns, nil
+}
+
+func (t *Tokenizer) fallback(r rune) (string, bool) {
+	mapped, ok := fallbackSymbols[r]
+	if !ok {
+		return "", false
+	}
+	for _, m := range mapped {
+		if _, ok := t.symbolToIndex[m]; !ok {
+			return "", false
+		}
+	}
+	return mapped, true
+}
@@ -1,5 +1,7 @@
 package tokenizer
 
+import "fmt"
+
 var symbols = []rune{
 	'_', ';', ':', ',', '.', '!', '?', '¡', '¿', '—', '…', '"', '«', '»', '"', '"',
 	' ', 'A', 'B', 'C', 'D', 'E', 'F', 'G', 'H', 'I', 'J', 'K', 'L', 'M', 'N', 'O',
@@ -19,34 +21,191 @@ var symbols = []rune{
 type Tokenizer struct {
 	symbolToIndex map[rune]int64
 	padIndex      int64
+	prefix        []int64
+	suffix        []int64
+	intersperse   bool
+	vocabSize     int
+	oovPolicy     OOVPolicy
 }
 
+// Framing selects how phoneme ids are wrapped before they reach the model.
+type Framing int
+
+const (
+	// FrameLeadingPad prepends a single pad: [0, ids...] (Kitten).
+	FrameLeadingPad Framing = iota
+	// FramePadded pads both ends: [0, ids..., 0] (Kokoro).
+	FramePadded
+	// FramePiper adds BOS/EOS and follows every id with a pad:
+	// [^, _, id, _, id, _, ..., $] (Piper).
+	FramePiper
+)
+
+const (
+	piperPad = "_"
+	piperBOS = "^"
+	piperEOS = "$"
+)
+
 func NewTokenizer() *Tokenizer {
-	symbolToIndex := make(map[rune]int64)
+	t, _ := New(BuiltinVocab(), FrameLeadingPad)
+	t.vocabSize = len(symbols) + 1
+	return t
+}
+
+// BuiltinVocab returns the StyleTTS symbol table used when a model ships
+// without its own vocabulary.
+func BuiltinVocab() map[string][]int64 {
+	vocab := make(map[string][]int64, len(symbols))
 	for i, s := range symbols {
-		symbolToIndex[s] = int64(i)
+		vocab[string(s)] = []int64{int64(i)}
+	}
+	return vocab
+}
+
+// New builds a tokenizer from a symbol-to-ids vocabulary. Only
+// single-rune symbols are used; the first id of each entry is taken.
+func New(vocab map[string][]int64, framing Framing) (*Tokenizer, error) {
+	symbolToIndex := make(map[rune]int64, len(vocab))
+	var maxID int64
+	for symbol, ids := range vocab {
+		for _, id := range ids {
+			maxID = max(maxID, id)
+		}
+		runes := []rune(symbol)
+		if len(runes) != 1 || len(ids) == 0 {
+			continue
+		}
+		symbolToIndex[runes[0]] = ids[0]
+	}
+	if len(symbolToIndex) == 0 {
+		return nil, fmt.Errorf("vocabulary has no usable symbols")
 	}
 
-	return &Tokenizer{
+	t := &Tokenizer{
 		symbolToIndex: symbolToIndex,
 		padIndex:      0,
+		vocabSize:     int(maxID) + 1,
 	}
+
+	switch framing {
+	case FrameLeadingPad:
+		t.prefix = []int64{t.padIndex}
+	case FramePadded:
+		t.prefix = []int64{t.padIndex}
+		t.suffix = []int64{t.padIndex}
+	case FramePiper:
+		lookup := func(symbol string) (int64, error) {
+			ids := vocab[symbol]
+			if len(ids) == 0 {
+				return 0, fmt.Errorf("vocabulary has no %q symbol", symbol)
+			}
+			return ids[0], nil
+		}
+
+		pad, err := lookup(piperPad)
+		if err != nil {
+			return nil, err
+		}
+		bos, err := lookup(piperBOS)
+		if err != nil {
+			return nil, err
+		}
+		eos, err := lookup(piperEOS)
+		if err != nil {
+			return nil, err
+		}
+
+		t.padIndex = pad
+		t.prefix = []int64{bos, pad}
+		t.suffix = []int64{eos}
+		t.intersperse = true
+	default:
+		return nil, fmt.Errorf("unknown framing: %d", framing)
+	}
+
+	return t, nil
+}
+
+func NewPiperTokenizer(idMap map[string][]int64) (*Tokenizer, error) {
+	return New(idMap, FramePiper)
 }
 
 func (t *Tokenizer) Encode(text string) []int64 {
-	tokens := make([]int64, 0, len(text)+1)
+	return t.Frame(t.EncodePhonemes(text))
+}
 
-	tokens = append(tokens, t.padIndex)
+// EncodePhonemes maps phonemes to ids without any model-specific framing.
+func (t *Tokenizer) EncodePhonemes(text string) []int64 {
+	ids := make([]int64, 0, len(text))
 
 	for _, r := range text {
 		if idx, ok := t.symbolToIndex[r]; ok {
-			tokens = append(tokens, idx)
+			ids = append(ids, idx)
 		}
 	}
 
+	return ids
+}
+
+// Frame wraps phoneme ids in the pad/BOS/EOS layout the model expects.
+func (t *Tokenizer) Frame(ids []int64) []int64 {
+	size := len(t.prefix) + len(ids) + len(t.suffix)
+	if t.intersperse {
+		size += len(ids)
+	}
+	tokens := make([]int64, 0, size)
+
+	tokens = append(tokens, t.prefix...)
+	for _, id := range ids {
+		tokens = append(tokens, id)
+		if t.intersperse {
+			tokens = append(tokens, t.padIndex)
+		}
+	}
+	tokens = append(tokens, t.suffix...)
+
 	return tokens
 }
 
+// Capacity returns how many phoneme ids fit in maxTokens once framed.
+func (t *Tokenizer) Capacity(maxTokens int) int {
+	n := maxTokens - len(t.prefix) - len(t.suffix)
+	if t.intersperse {
+		n /= 2
+	}
+	return max(n, 0)
+}
+
+// TokenSpan returns the range of framed token positions produced by the
+// i-th phoneme id, including the pad interspersed after it.
+func (t *Tokenizer) TokenSpan(i int) (int, int) {
+	width := 1
+	if t.intersperse {
+		width = 2
+	}
+	start := len(t.prefix) + i*width
+	return start, start + width
+}
+
+// Symbols maps ids back to their vocabulary symbols.
+func (t *Tokenizer) Symbols(ids []int64) []string {
+	byID := make(map[int64]rune, len(t.symbolToIndex))
+	for r, id := range t.symbolToIndex {
+		if prev, ok := byID[id]; !ok || r < prev {
+			byID[id] = r
+		}
+	}
+
+	symbols := make([]string, len(ids))
+	for i, id := range ids {
+		if r, ok := byID[id]; ok {
+			symbols[i] = string(r)
+		}
+	}
+	return symbols
+}
+
 func (t *Tokenizer) VocabSize() int {
-	return len(symbols) + 1
+	return t.vocabSize
 }
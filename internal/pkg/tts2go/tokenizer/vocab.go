@@ -0,0 +1,96 @@
+package tokenizer
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+var ErrNoVocab = errors.New("no vocabulary found")
+
+// LoadVocab reads a symbol-to-ids vocabulary from one of:
+//   - a JSON config with a "vocab" object (Kokoro config.json)
+//   - a JSON config with a "phoneme_id_map" object (Piper .onnx.json)
+//   - a "symbol id" per line text file (Piper/sherpa-onnx tokens.txt)
+func LoadVocab(path string) (map[string][]int64, error) {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		return loadJSONVocab(path)
+	case ".txt":
+		return loadTextVocab(path)
+	default:
+		return nil, fmt.Errorf("unsupported vocabulary file: %s", path)
+	}
+}
+
+func loadJSONVocab(path string) (map[string][]int64, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read vocabulary: %w", err)
+	}
+
+	var cfg struct {
+		Vocab        map[string]int64   `json:"vocab"`
+		PhonemeIDMap map[string][]int64 `json:"phoneme_id_map"`
+	}
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("failed to parse vocabulary %s: %w", path, err)
+	}
+
+	if len(cfg.PhonemeIDMap) > 0 {
+		return cfg.PhonemeIDMap, nil
+	}
+	if len(cfg.Vocab) > 0 {
+		vocab := make(map[string][]int64, len(cfg.Vocab))
+		for symbol, id := range cfg.Vocab {
+			vocab[symbol] = []int64{id}
+		}
+		return vocab, nil
+	}
+
+	return nil, fmt.Errorf("%s: %w", path, ErrNoVocab)
+}
+
+func loadTextVocab(path string) (map[string][]int64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read vocabulary: %w", err)
+	}
+	defer f.Close()
+
+	vocab := make(map[string][]int64)
+	scanner := bufio.NewScanner(f)
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+
+		// The symbol itself may be a space, so split on the last one.
+		sep := strings.LastIndexByte(line, ' ')
+		if sep < 0 {
+			return nil, fmt.Errorf("%s:%d: expected \"symbol id\"", path, lineNum)
+		}
+		id, err := strconv.ParseInt(line[sep+1:], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: invalid id: %w", path, lineNum, err)
+		}
+		symbol := line[:sep]
+		vocab[symbol] = append(vocab[symbol], id)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read vocabulary: %w", err)
+	}
+
+	if len(vocab) == 0 {
+		return nil, fmt.Errorf("%s: %w", path, ErrNoVocab)
+	}
+	return vocab, nil
+}
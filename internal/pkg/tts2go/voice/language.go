@@ -0,0 +1,27 @@
+package voice
+
+// kokoroLanguages maps the first letter of a Kokoro voice name to the
+// language it was trained on, e.g. "bf_emma" is British English.
+var kokoroLanguages = map[byte]string{
+	'a': "en",
+	'b': "en-gb",
+	'e': "es",
+	'f': "fr",
+	'h': "hi",
+	'i': "it",
+	'j': "ja",
+	'p': "pt-br",
+	'z': "zh",
+}
+
+// KokoroLanguage returns the language code implied by a Kokoro voice name
+// such as "af_bella" or "ef_dora", or "" if the name has no known prefix.
+func KokoroLanguage(name string) string {
+	if len(name) < 2 || (name[1] != 'f' && name[1] != 'm') {
+		return ""
+	}
+	if len(name) > 2 && name[2] != '_' {
+		return ""
+	}
+	return kokoroLanguages[name[0]]
+}
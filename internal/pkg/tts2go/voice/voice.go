@@ -48,26 +48,37 @@ func LoadVoices(path string) (*VoiceStore, error) {
 			return nil, fmt.Errorf("failed to read %s: %w", f.Name, err)
 		}
 
-		if len(shape) == 2 && shape[1] == expectedEmbeddingDim {
-			store.voices[name] = data[:expectedEmbeddingDim]
-		} else if len(data) == expectedEmbeddingDim {
-			store.voices[name] = data
-		} else if len(data) > expectedEmbeddingDim {
-			store.voices[name] = data[:expectedEmbeddingDim]
-		} else {
-			store.voices[name] = data
-		}
+		store.voices[name] = styleTable(data, shape)
 	}
 
 	return store, nil
 }
 
 func (v *VoiceStore) Get(name string) ([]float32, error) {
-	voice, ok := v.voices[name]
+	return v.Style(name, 0)
+}
+
+// Style returns the style vector for an utterance of numTokens phoneme
+// tokens. Voice packs holding a per-length table (Kokoro's [510,1,256])
+// are indexed by length; shorter tables are clamped to their last row.
+func (v *VoiceStore) Style(name string, numTokens int) ([]float32, error) {
+	table, ok := v.voices[name]
 	if !ok {
 		return nil, fmt.Errorf("voice not found: %s", name)
 	}
-	return voice, nil
+
+	rows := len(table) / v.embeddingDim
+	if rows <= 1 {
+		return table, nil
+	}
+
+	idx := numTokens
+	if idx < 0 {
+		idx = 0
+	} else if idx >= rows {
+		idx = rows - 1
+	}
+	return table[idx*v.embeddingDim : (idx+1)*v.embeddingDim], nil
 }
 
 func (v *VoiceStore) List() []string {
@@ -274,14 +285,7 @@ func loadNpyVoice(path string) ([]float32, error) {
 		return nil, err
 	}
 
-	if len(shape) == 2 && shape[1] == expectedEmbeddingDim {
-		return data[:expectedEmbeddingDim], nil
-	} else if len(data) == expectedEmbeddingDim {
-		return data, nil
-	} else if len(data) > expectedEmbeddingDim {
-		return data[:expectedEmbeddingDim], nil
-	}
-	return data, nil
+	return styleTable(data, shape), nil
 }
 
 func loadBinVoice(path string) ([]float32, error) {
@@ -301,5 +305,15 @@ func loadBinVoice(path string) ([]float32, error) {
 		floats[i] = math.Float32frombits(bits)
 	}
 
-	return floats[:expectedEmbeddingDim], nil
+	return styleTable(floats, nil), nil
+}
+
+func styleTable(data []float32, shape []int) []float32 {
+	if len(data) <= expectedEmbeddingDim {
+		return data
+	}
+	if len(shape) > 0 && shape[len(shape)-1] != expectedEmbeddingDim {
+		return data[:expectedEmbeddingDim]
+	}
+	return data[:len(data)-len(data)%expectedEmbeddingDim]
 }